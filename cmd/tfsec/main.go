@@ -10,9 +10,15 @@ import (
 
 	"github.com/liamg/tml"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/baseline"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/changes"
 	_ "github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/config"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/custom"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/exitcode"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/security"
 	"github.com/hemanthgk10/tfsec/version"
 	"github.com/spf13/cobra"
 )
@@ -23,8 +29,47 @@ var format string
 var softFail = false
 var excludedChecks string
 var excludeDirectories []string
-var tfvarsPath string
 var outputFlag string
+var listRoots = false
+var softFailParseErrors = false
+var showSecrets = false
+var secretPatterns []string
+var customCheckDir string
+var configFile string
+var minimumSeverity string
+var severityOverrides []string
+var writeBaselinePath string
+var baselinePath string
+var sinceRef string
+var concurrency int
+var includeIgnored = false
+var strictIgnores = false
+
+// variableArg is a --tfvars-file or --var argument. Terraform applies these in the order they were given, regardless of
+// which flag was used, so both flags record into the same list.
+type variableArg struct {
+	isFile bool
+	value  string
+}
+
+var variableArgs []variableArg
+
+type variableArgFlag struct {
+	isFile bool
+}
+
+func (f *variableArgFlag) String() string {
+	return ""
+}
+
+func (f *variableArgFlag) Set(value string) error {
+	variableArgs = append(variableArgs, variableArg{isFile: f.isFile, value: value})
+	return nil
+}
+
+func (f *variableArgFlag) Type() string {
+	return "string"
+}
 
 func init() {
 	rootCmd.Flags().BoolVar(&disableColours, "no-colour", disableColours, "Disable coloured output")
@@ -34,8 +79,23 @@ func init() {
 	rootCmd.Flags().StringVarP(&excludedChecks, "exclude", "e", excludedChecks, "Provide checks via , without space to exclude from run.")
 	rootCmd.Flags().BoolVarP(&softFail, "soft-fail", "s", softFail, "Runs checks but suppresses error code")
 	rootCmd.Flags().StringSliceVar(&excludeDirectories, "exclude-dir", []string{}, "Exclude a directory from the scan. You can use this flag multiple times to exclude further directories.")
-	rootCmd.Flags().StringVar(&tfvarsPath, "tfvars-file", tfvarsPath, "Path to .tfvars file")
+	rootCmd.Flags().Var(&variableArgFlag{isFile: true}, "tfvars-file", "Path to .tfvars file. You can use this flag multiple times to include further files.")
+	rootCmd.Flags().Var(&variableArgFlag{}, "var", "Set a variable in the form name=value. You can use this flag multiple times to set further variables.")
 	rootCmd.Flags().StringVar(&outputFlag, "out", outputFlag, "Set output file")
+	rootCmd.Flags().BoolVar(&listRoots, "list-roots", listRoots, "List the root modules which would be scanned and exit")
+	rootCmd.Flags().BoolVar(&showSecrets, "show-secrets", showSecrets, "Show the values of passwords and other secrets in the output, rather than masking them")
+	rootCmd.Flags().StringVar(&minimumSeverity, "minimum-severity", minimumSeverity, "Only report problems of at least this severity: ERROR, WARNING or INFO")
+	rootCmd.Flags().StringSliceVar(&severityOverrides, "severity-override", []string{}, "Override the severity of a check in the form CODE=SEVERITY e.g. AWS018=ERROR. You can use this flag multiple times to override further checks.")
+	rootCmd.Flags().StringVar(&writeBaselinePath, "write-baseline", writeBaselinePath, "Record the problems found in a baseline file and exit, so later scans with --baseline only report new problems")
+	rootCmd.Flags().StringVar(&baselinePath, "baseline", baselinePath, "Only report problems which are not in this baseline file, along with problems from it which have been fixed")
+	rootCmd.Flags().StringVar(&sinceRef, "since", sinceRef, "Only report problems in terraform files and lines which have changed since this git ref, e.g. origin/main")
+	rootCmd.Flags().StringVar(&configFile, "config", configFile, "Read settings from this configuration file instead of the .tfsec.yml files in and above the scanned directory")
+	rootCmd.Flags().StringVar(&customCheckDir, "custom-check-dir", customCheckDir, "Load custom checks from this directory instead of the .tfsec directory of the scanned directory")
+	rootCmd.Flags().StringArrayVar(&secretPatterns, "secret-pattern", []string{}, "Detect secrets matching a regular expression, in the form name=regex. You can use this flag multiple times to add further patterns.")
+	rootCmd.Flags().BoolVar(&includeIgnored, "include-ignored", includeIgnored, "Also list the problems which have been ignored with tfsec:ignore comments, along with the reason each was ignored")
+	rootCmd.Flags().BoolVar(&strictIgnores, "strict-ignores", strictIgnores, "Fail the scan if any tfsec:ignore comment is no longer needed, has expired, or names a check which doesn't exist")
+	rootCmd.Flags().IntVar(&concurrency, "concurrency", concurrency, "Number of blocks to scan at the same time (defaults to the number of CPUs)")
+	rootCmd.Flags().BoolVar(&softFailParseErrors, "soft-fail-parse-errors", softFailParseErrors, "Report files which could not be parsed without failing the scan")
 }
 
 func main() {
@@ -81,6 +141,28 @@ var rootCmd = &cobra.Command{
 			excludedChecksList = strings.Split(excludedChecks, ",")
 		}
 
+		flagConfig, err := config.FromFlags(format, minimumSeverity, severityOverrides)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		var absoluteExcludes []string
+		for _, exclude := range excludeDirectories {
+			exDir, err := filepath.Abs(exclude)
+			if err != nil {
+				continue
+			}
+			absoluteExcludes = append(absoluteExcludes, exDir)
+		}
+
+		scanConfig, err := config.LoadTree(dir, configFile, flagConfig, absoluteExcludes)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		baseConfig := scanConfig.Base()
+
 		if outputFlag != "" {
 			f, err := os.OpenFile(filepath.Clean(outputFlag), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
 			if err != nil {
@@ -93,50 +175,222 @@ var rootCmd = &cobra.Command{
 			outputFile = os.Stdout
 		}
 
-		formatter, err := getFormatter()
+		formatter, err := getFormatter(baseConfig.Format)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		var absoluteExcludes []string
-		for _, exclude := range excludeDirectories {
-			exDir, err := filepath.Abs(exclude)
-			if err != nil {
-				continue
-			}
-			absoluteExcludes = append(absoluteExcludes, exDir)
+		if err := registerCustomChecks(dir, baseConfig); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		if err := addSecretPatterns(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
 		}
 
-		if tfvarsPath != "" {
-			tfvarsPath, err = filepath.Abs(tfvarsPath)
+		parserOptions, err := getParserOptions(scanConfig)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		tfParser := parser.New(parserOptions...)
+
+		if listRoots {
+			roots, err := tfParser.FindRootModules(dir, absoluteExcludes)
 			if err != nil {
 				fmt.Println(err)
 				os.Exit(1)
 			}
+			for _, root := range roots {
+				if relative, err := filepath.Rel(dir, root); err == nil {
+					root = relative
+				}
+				fmt.Println(root)
+			}
+			os.Exit(0)
 		}
 
-		blocks, err := parser.New().ParseDirectory(dir, absoluteExcludes, tfvarsPath)
+		blocks, err := tfParser.ParseDirectory(dir, absoluteExcludes)
 		if err != nil {
 			fmt.Println(err)
 			os.Exit(1)
 		}
 
-		results := scanner.New().Scan(blocks, excludedChecksList)
-		if err := formatter(outputFile, results); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
+		for _, module := range tfParser.UnresolvedModules() {
+			fmt.Fprintf(os.Stderr, "WARNING: skipped module '%s' (source '%s') at %s: %s\n", module.Key, module.Source, module.Range.String(), module.Reason)
+		}
+
+		diagnostics := tfParser.Diagnostics()
+
+		scannerOptions := []scanner.Option{
+			scanner.OptionWithConcurrency(concurrency),
+			scanner.OptionWithSources(tfParser.Sources()),
+		}
+		if includeIgnored {
+			scannerOptions = append(scannerOptions, scanner.OptionIncludeIgnored())
 		}
+		tfScanner := scanner.New(scannerOptions...)
+		results, ignored := splitIgnored(scanConfig.Apply(tfScanner.Scan(blocks, excludedChecksList)))
+		ignoreWarnings := tfScanner.IgnoreWarnings()
 
-		if len(results) == 0 || softFail {
+		if writeBaselinePath != "" {
+			if err := baseline.New(dir, results).Write(writeBaselinePath); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Recorded %d problems in baseline %s\n", len(results), writeBaselinePath)
 			os.Exit(0)
 		}
 
-		os.Exit(1)
+		if baselinePath != "" {
+			existing, err := baseline.Load(baselinePath)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			var fixed []baseline.Entry
+			results, fixed = existing.Compare(dir, results)
+			reportFixed(fixed)
+		}
+		if sinceRef != "" {
+			changed, err := changes.Since(dir, sinceRef)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			results = changed.Filter(results)
+			ignored = changed.Filter(ignored)
+			ignoreWarnings = filterIgnoreWarnings(ignoreWarnings, changed)
+		}
+		reportIgnoreWarnings(ignoreWarnings)
+		output := append(results, ignored...)
+		if showSecrets {
+			for i := range output {
+				output[i].Secrets = nil
+			}
+			tfParser.Sources().ClearSecrets()
+		}
+		if err := formatter(outputFile, output, diagnostics, tfParser.Sources()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		os.Exit(exitcode.Get(results, diagnostics, ignoreWarnings, exitcode.Options{
+			SoftFail:            softFail,
+			SoftFailParseErrors: softFailParseErrors,
+			StrictIgnores:       strictIgnores,
+		}))
 	},
 }
 
-func getFormatter() (formatters.Formatter, error) {
+// splitIgnored separates the results which have been ignored with a comment, which are only found with
+// --include-ignored, from the problems which count towards the exit code and baseline
+func splitIgnored(results []scanner.Result) ([]scanner.Result, []scanner.Result) {
+	var problems []scanner.Result
+	var ignored []scanner.Result
+	for _, result := range results {
+		if result.Ignored != nil {
+			ignored = append(ignored, result)
+		} else {
+			problems = append(problems, result)
+		}
+	}
+	return problems, ignored
+}
+
+// reportFixed lists the problems from the baseline which are no longer found
+func reportFixed(fixed []baseline.Entry) {
+	if len(fixed) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%d problems in the baseline have been fixed:\n", len(fixed))
+	for _, entry := range fixed {
+		fmt.Fprintf(os.Stderr, "  [%s] %s (was at %s)\n", entry.RuleID, entry.Description, entry.Location)
+	}
+}
+
+// reportIgnoreWarnings lists the ignore comments which didn't ignore any problems
+func reportIgnoreWarnings(warnings []scanner.IgnoreWarning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(os.Stderr, "WARNING: %s at %s\n", warning.Message(), warning.Ignore.Range.String())
+	}
+}
+
+// filterIgnoreWarnings returns the warnings about ignore comments on lines which have changed
+func filterIgnoreWarnings(warnings []scanner.IgnoreWarning, changed *changes.Changes) []scanner.IgnoreWarning {
+	var filtered []scanner.IgnoreWarning
+	for _, warning := range warnings {
+		if changed.Contains(warning.Ignore.Range) {
+			filtered = append(filtered, warning)
+		}
+	}
+	return filtered
+}
+
+// registerCustomChecks registers the checks in the --custom-check-dir directory, or if it isn't set, the configured
+// custom check directories, or failing that the .tfsec directory of the scanned directory if there is one
+func registerCustomChecks(dir string, scanConfig *config.Config) error {
+	checkDirs := scanConfig.CustomCheckDirs
+	if customCheckDir != "" {
+		checkDirs = []string{customCheckDir}
+	}
+	if len(checkDirs) == 0 {
+		return custom.LoadAndRegisterCheckDir(filepath.Join(dir, custom.DefaultCheckDir))
+	}
+	var checks []*custom.Check
+	for _, checkDir := range checkDirs {
+		dirChecks, err := custom.LoadCheckDir(checkDir)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, dirChecks...)
+	}
+	return custom.RegisterChecks(checks)
+}
+
+func addSecretPatterns() error {
+	for _, pattern := range secretPatterns {
+		parts := strings.SplitN(pattern, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return fmt.Errorf("invalid secret pattern specified: '%s' (expected name=regex)", pattern)
+		}
+		if err := security.AddSecretPattern(parts[0], parts[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getParserOptions returns the options for the parser, with variables from the configured tfvars files overridden by
+// those given with --tfvars-file and --var, and the configured exclude_paths left out of the scan
+func getParserOptions(scanConfig *config.Tree) ([]parser.Option, error) {
+	options := []parser.Option{
+		parser.OptionWithExcludedPaths(scanConfig.IsPathExcluded),
+		parser.OptionWithRootTFVarsFiles(scanConfig.TFVarsFiles),
+	}
+	for _, arg := range variableArgs {
+		if arg.isFile {
+			tfvarsPath, err := filepath.Abs(arg.value)
+			if err != nil {
+				return nil, err
+			}
+			options = append(options, parser.OptionWithTFVarsFile(tfvarsPath))
+			continue
+		}
+		parts := strings.SplitN(arg.value, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid variable specified: '%s' (expected name=value)", arg.value)
+		}
+		options = append(options, parser.OptionWithVariable(parts[0], parts[1]))
+	}
+	return options, nil
+}
+
+func getFormatter(format string) (formatters.Formatter, error) {
 	switch format {
 	case "", "default":
 		return formatters.FormatDefault, nil
@@ -3,6 +3,7 @@ module github.com/hemanthgk10/tfsec
 go 1.14
 
 require (
+	github.com/bmatcuk/doublestar v1.1.5
 	github.com/hashicorp/hcl/v2 v2.3.0
 	github.com/hashicorp/terraform v0.12.28
 	github.com/liamg/clinch v1.3.0
@@ -11,4 +12,5 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/zclconf/go-cty v1.5.1
 	github.com/zclconf/go-cty-yaml v1.0.1
+	gopkg.in/yaml.v2 v2.2.2
 )
@@ -143,6 +143,56 @@ variable "cidr_2" {
 `,
 			mustIncludeResultCode: checks.AWSOpenEgressSecurityGroupInlineRule,
 		},
+		{
+			name: "check dynamic block iterator value containing 0.0.0.0/0",
+			source: `
+variable "ingress_rules" {
+	default = [
+		{ port = 22, cidr_blocks = ["10.0.0.0/16"] },
+		{ port = 443, cidr_blocks = ["0.0.0.0/0"] },
+	]
+}
+
+resource "aws_security_group" "my-group" {
+	dynamic "ingress" {
+		for_each = var.ingress_rules
+		content {
+			from_port   = ingress.value.port
+			to_port     = ingress.value.port
+			cidr_blocks = ingress.value.cidr_blocks
+		}
+	}
+}`,
+			mustIncludeResultCode: checks.AWSOpenIngressSecurityGroupInlineRule,
+		},
+		{
+			name: "check dynamic block custom iterator containing 0.0.0.0/0",
+			source: `
+resource "aws_security_group" "my-group" {
+	dynamic "ingress" {
+		for_each = ["0.0.0.0/0"]
+		iterator = cidr
+		content {
+			cidr_blocks = [cidr.value]
+		}
+	}
+}`,
+			mustIncludeResultCode: checks.AWSOpenIngressSecurityGroupInlineRule,
+		},
+		{
+			name: "check dynamic block iterator value not containing 0.0.0.0/0",
+			source: `
+resource "aws_security_group" "my-group" {
+	dynamic "ingress" {
+		for_each = { internal = "10.0.0.0/16" }
+		content {
+			description = ingress.key
+			cidr_blocks = [ingress.value]
+		}
+	}
+}`,
+			mustExcludeResultCode: checks.AWSOpenIngressSecurityGroupInlineRule,
+		},
 		{
 			name: "check aws_security_group ingress on ::/0",
 			source: `
@@ -0,0 +1,119 @@
+package baseline
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+const version = 1
+
+// Baseline is a record of the results of a scan, so that later scans can report only the problems which are new
+type Baseline struct {
+	Version int     `json:"version"`
+	Entries []Entry `json:"results"`
+}
+
+// Entry is a result recorded in a baseline. Results are matched by their root module and fingerprint; the rest is
+// kept to describe the problem once it has been fixed.
+type Entry struct {
+	RuleID      scanner.RuleID `json:"rule_id"`
+	RootModule  string         `json:"root_module"`
+	Fingerprint string         `json:"fingerprint"`
+	Description string         `json:"description"`
+	Location    string         `json:"location"`
+}
+
+// New creates a baseline of the given results from a scan of the given directory. Root modules and locations are
+// recorded relative to the directory, so the baseline still matches when the directory is checked out elsewhere.
+func New(dir string, results []scanner.Result) *Baseline {
+	baseline := &Baseline{
+		Version: version,
+		Entries: []Entry{},
+	}
+	for _, result := range results {
+		location := result.Range
+		location.Filename = relativePath(dir, location.Filename)
+		baseline.Entries = append(baseline.Entries, Entry{
+			RuleID:      result.RuleID,
+			RootModule:  relativePath(dir, result.RootModule),
+			Fingerprint: result.Fingerprint,
+			Description: result.Description,
+			Location:    location.String(),
+		})
+	}
+	return baseline
+}
+
+// Load reads the baseline at the given path
+func Load(path string) (*Baseline, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var baseline Baseline
+	if err := json.Unmarshal(data, &baseline); err != nil {
+		return nil, fmt.Errorf("%s: failed to load baseline: %s", path, err)
+	}
+	if baseline.Version != version {
+		return nil, fmt.Errorf("%s: unsupported baseline version %d", path, baseline.Version)
+	}
+	return &baseline, nil
+}
+
+// Write saves the baseline to the given path
+func (baseline *Baseline) Write(path string) error {
+	data, err := json.MarshalIndent(baseline, "", "\t")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Compare splits the results of a scan of the given directory into those which are not in the baseline, and returns
+// them along with the baseline entries which no longer appear in the results because they have been fixed
+func (baseline *Baseline) Compare(dir string, results []scanner.Result) ([]scanner.Result, []Entry) {
+
+	remaining := make(map[string]int)
+	for _, entry := range baseline.Entries {
+		remaining[entry.key()]++
+	}
+
+	var newResults []scanner.Result
+	for _, result := range results {
+		key := Entry{RootModule: relativePath(dir, result.RootModule), Fingerprint: result.Fingerprint}.key()
+		if remaining[key] > 0 {
+			remaining[key]--
+			continue
+		}
+		newResults = append(newResults, result)
+	}
+
+	var fixed []Entry
+	for _, entry := range baseline.Entries {
+		if remaining[entry.key()] > 0 {
+			remaining[entry.key()]--
+			fixed = append(fixed, entry)
+		}
+	}
+
+	return newResults, fixed
+}
+
+func (entry Entry) key() string {
+	return entry.RootModule + "|" + entry.Fingerprint
+}
+
+func relativePath(dir string, path string) string {
+	if path == "" {
+		return ""
+	}
+	relative, err := filepath.Rel(dir, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(relative)
+}
@@ -0,0 +1,68 @@
+package baseline
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func result(dir string, root string, code scanner.RuleID, fingerprint string) scanner.Result {
+	return scanner.Result{
+		RuleID:      code,
+		RootModule:  filepath.Join(dir, root),
+		Fingerprint: fingerprint,
+		Description: "problem " + fingerprint,
+		Range:       parser.Range{Filename: filepath.Join(dir, root, "main.tf"), StartLine: 3, EndLine: 3},
+	}
+}
+
+func Test_BaselineReportsNewAndFixedProblems(t *testing.T) {
+
+	dir, err := ioutil.TempDir(os.TempDir(), "tfsec")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "baseline.json")
+
+	require.NoError(t, New(dir, []scanner.Result{
+		result(dir, "envs/dev", "AWS006", "aaaa"),
+		result(dir, "envs/prod", "AWS006", "aaaa"),
+		result(dir, "envs/prod", "AWS017", "bbbb"),
+	}).Write(path))
+
+	loaded, err := Load(path)
+	require.NoError(t, err)
+	assert.Equal(t, "envs/prod", loaded.Entries[1].RootModule)
+	assert.Equal(t, "envs/prod/main.tf:3", loaded.Entries[1].Location)
+
+	// the same repository checked out somewhere else, with one problem fixed and one introduced
+	moved := filepath.Join(dir, "elsewhere")
+	newResults, fixed := loaded.Compare(moved, []scanner.Result{
+		result(moved, "envs/dev", "AWS006", "aaaa"),
+		result(moved, "envs/dev", "AWS017", "bbbb"),
+		result(moved, "envs/prod", "AWS006", "aaaa"),
+	})
+
+	require.Len(t, newResults, 1)
+	assert.Equal(t, filepath.Join(moved, "envs/dev"), newResults[0].RootModule)
+	assert.Equal(t, scanner.RuleID("AWS017"), newResults[0].RuleID)
+
+	require.Len(t, fixed, 1)
+	assert.Equal(t, "envs/prod", fixed[0].RootModule)
+	assert.Equal(t, scanner.RuleID("AWS017"), fixed[0].RuleID)
+}
+
+func Test_UnsupportedBaseline(t *testing.T) {
+	dir, err := ioutil.TempDir(os.TempDir(), "tfsec")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "baseline.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"version": 99, "results": []}`), 0600))
+
+	_, err = Load(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,179 @@
+package changes
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// pathspecs limit the changes to terraform files
+var pathspecs = []string{"*.tf", "*.tf.json"}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,(\d+))? @@`)
+
+// lineRange is a range of changed lines, from start to end inclusive
+type lineRange struct {
+	start int
+	end   int
+}
+
+// Changes are the lines of terraform files which have changed since a git ref
+type Changes struct {
+	// files maps the absolute path of each changed file to its changed lines, or to nil if the whole file is new
+	files map[string][]lineRange
+}
+
+// Since finds the terraform files in the git repository containing dir which have been changed since the given ref,
+// including changes which haven't been committed yet and new files which haven't been added
+func Since(dir string, ref string) (*Changes, error) {
+
+	root, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	root, err = resolve(strings.TrimSpace(root))
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("unknown git ref '%s'", ref)
+	}
+
+	diff, err := git(root, append([]string{
+		"-c", "core.quotePath=false", "diff", "--no-color", "--no-ext-diff", "--unified=0",
+		"--src-prefix=a/", "--dst-prefix=b/", ref, "--",
+	}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	changes, err := parseDiff(root, diff)
+	if err != nil {
+		return nil, err
+	}
+
+	untracked, err := git(root, append([]string{
+		"-c", "core.quotePath=false", "ls-files", "--others", "--exclude-standard", "--",
+	}, pathspecs...)...)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range strings.Split(strings.TrimSpace(untracked), "\n") {
+		if path != "" {
+			changes.files[filepath.Join(root, filepath.FromSlash(path))] = nil
+		}
+	}
+
+	return changes, nil
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], message)
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], err)
+	}
+	return string(output), nil
+}
+
+// parseDiff reads the changed lines of each file from a diff with no context lines. A hunk which only removes lines is
+// recorded as changing the lines either side of it, so that problems caused by removing an attribute are found.
+func parseDiff(root string, diff string) (*Changes, error) {
+
+	changes := &Changes{files: make(map[string][]lineRange)}
+
+	var path string
+	reader := bufio.NewScanner(strings.NewReader(diff))
+	reader.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for reader.Scan() {
+		line := reader.Text()
+		switch {
+		case strings.HasPrefix(line, "+++ "):
+			path = ""
+			if name := strings.TrimPrefix(line, "+++ "); strings.HasPrefix(name, "b/") {
+				path = filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(name, "b/")))
+				if _, exists := changes.files[path]; !exists {
+					changes.files[path] = []lineRange{}
+				}
+			}
+		case strings.HasPrefix(line, "@@ ") && path != "":
+			matches := hunkHeader.FindStringSubmatch(line)
+			if matches == nil {
+				return nil, fmt.Errorf("failed to read git diff: unexpected hunk header '%s'", line)
+			}
+			start, _ := strconv.Atoi(matches[1])
+			count := 1
+			if matches[2] != "" {
+				count, _ = strconv.Atoi(matches[2])
+			}
+			changed := lineRange{start: start, end: start + count - 1}
+			if count == 0 {
+				changed = lineRange{start: start, end: start + 1}
+			}
+			changes.files[path] = append(changes.files[path], changed)
+		}
+	}
+	if err := reader.Err(); err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// resolve returns the absolute path with any symlinks followed, so paths from git and from the parser can be compared
+func resolve(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved, nil
+	}
+	return path, nil
+}
+
+// Contains returns true if any of the lines in the given range have changed
+func (changes *Changes) Contains(r parser.Range) bool {
+	path, err := resolve(r.Filename)
+	if err != nil {
+		return false
+	}
+	changed, exists := changes.files[path]
+	if !exists {
+		return false
+	}
+	if changed == nil {
+		return true
+	}
+	for _, lines := range changed {
+		if lines.start <= r.EndLine && r.StartLine <= lines.end {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter returns the results which are in changed lines
+func (changes *Changes) Filter(results []scanner.Result) []scanner.Result {
+	var filtered []scanner.Result
+	for _, result := range results {
+		if changes.Contains(result.Range) {
+			filtered = append(filtered, result)
+		}
+	}
+	return filtered
+}
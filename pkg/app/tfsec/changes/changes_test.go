@@ -0,0 +1,104 @@
+package changes
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+)
+
+func lines(path string, start int, end int) parser.Range {
+	return parser.Range{Filename: path, StartLine: start, EndLine: end}
+}
+
+func Test_ParseDiff(t *testing.T) {
+
+	changes, err := parseDiff("/repo", `diff --git a/main.tf b/main.tf
+index 3b18e51..a1c3d8f 100644
+--- a/main.tf
++++ b/main.tf
+@@ -3 +3 @@ resource "aws_s3_bucket" "logs" {
+-	acl = "private"
++	acl = "public-read"
+@@ -10,2 +9,0 @@ resource "aws_ebs_volume" "data" {
+-	encrypted = true
+-	kms_key_id = "abc"
+diff --git a/network.tf b/network.tf
+deleted file mode 100644
+--- a/network.tf
++++ /dev/null
+@@ -1,3 +0,0 @@
+-resource "aws_vpc" "main" {
+-}
+diff --git a/modules/new.tf b/modules/new.tf
+new file mode 100644
+--- /dev/null
++++ b/modules/new.tf
+@@ -0,0 +1,4 @@
++resource "aws_security_group" "sg" {
+`)
+	require.NoError(t, err)
+
+	main := filepath.Join("/repo", "main.tf")
+	assert.True(t, changes.Contains(lines(main, 3, 3)))
+	assert.True(t, changes.Contains(lines(main, 1, 5)))
+	assert.False(t, changes.Contains(lines(main, 4, 8)))
+	assert.True(t, changes.Contains(lines(main, 7, 9)))
+	assert.True(t, changes.Contains(lines(main, 10, 12)))
+	assert.False(t, changes.Contains(lines(main, 11, 12)))
+	assert.False(t, changes.Contains(lines(filepath.Join("/repo", "network.tf"), 1, 3)))
+	assert.True(t, changes.Contains(lines(filepath.Join("/repo", "modules", "new.tf"), 2, 2)))
+	assert.False(t, changes.Contains(lines(filepath.Join("/repo", "modules", "new.tf"), 5, 6)))
+}
+
+func Test_ChangesSinceRef(t *testing.T) {
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir, err := ioutil.TempDir(os.TempDir(), "tfsec")
+	require.NoError(t, err)
+
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=tfsec", "-c", "user.email=tfsec@example.com"}, args...)...)
+		cmd.Dir = dir
+		output, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(output))
+	}
+	write := func(filename string, contents string) {
+		path := filepath.Join(dir, filename)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(contents), 0600))
+	}
+
+	run("init", "-q")
+	write("main.tf", "resource \"a\" \"a\" {\n}\n\nresource \"b\" \"b\" {\n}\n")
+	write("envs/prod/main.tf", "resource \"c\" \"c\" {\n}\n")
+	run("add", ".")
+	run("commit", "-q", "-m", "initial")
+	run("tag", "base")
+
+	write("main.tf", "resource \"a\" \"a\" {\n}\n\nresource \"b\" \"b\" {\n\tchanged = true\n}\n")
+	run("commit", "-q", "-am", "change b")
+	write("envs/dev/main.tf", "resource \"d\" \"d\" {\n}\n")
+	write("envs/prod/notes.txt", "not terraform\n")
+
+	changes, err := Since(filepath.Join(dir, "envs"), "base")
+	require.NoError(t, err)
+
+	assert.False(t, changes.Contains(lines(filepath.Join(dir, "main.tf"), 1, 2)))
+	assert.True(t, changes.Contains(lines(filepath.Join(dir, "main.tf"), 4, 6)))
+	assert.False(t, changes.Contains(lines(filepath.Join(dir, "envs", "prod", "main.tf"), 1, 2)))
+	assert.True(t, changes.Contains(lines(filepath.Join(dir, "envs", "dev", "main.tf"), 1, 2)))
+	assert.False(t, changes.Contains(lines(filepath.Join(dir, "envs", "prod", "notes.txt"), 1, 1)))
+
+	_, err = Since(dir, "no-such-ref")
+	assert.Error(t, err)
+}
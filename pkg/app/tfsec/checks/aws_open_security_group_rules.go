@@ -43,12 +43,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+				for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResult(
 								fmt.Sprintf("Resource '%s' defines a fully open ingress security group rule.", block.Name()),
-								cidrBlocksAttr.Range(),
+								cidrBlocksAttr.RangeOfElement(i),
 								scanner.SeverityWarning,
 							),
 						}
@@ -63,12 +63,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range ipv6CidrBlocksAttr.Value().AsValueSlice() {
+				for i, cidr := range ipv6CidrBlocksAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResultWithValueAnnotation(
 								fmt.Sprintf("Resource '%s' defines a fully open egress security group rule.", block.Name()),
-								ipv6CidrBlocksAttr.Range(),
+								ipv6CidrBlocksAttr.RangeOfElement(i),
 								ipv6CidrBlocksAttr,
 								scanner.SeverityWarning,
 							),
@@ -105,12 +105,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+				for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResultWithValueAnnotation(
 								fmt.Sprintf("Resource '%s' defines a fully open egress security group rule.", block.Name()),
-								cidrBlocksAttr.Range(),
+								cidrBlocksAttr.RangeOfElement(i),
 								cidrBlocksAttr,
 								scanner.SeverityWarning,
 							),
@@ -126,12 +126,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range ipv6CidrBlocksAttr.Value().AsValueSlice() {
+				for i, cidr := range ipv6CidrBlocksAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResultWithValueAnnotation(
 								fmt.Sprintf("Resource '%s' defines a fully open egress security group rule.", block.Name()),
-								ipv6CidrBlocksAttr.Range(),
+								ipv6CidrBlocksAttr.RangeOfElement(i),
 								ipv6CidrBlocksAttr,
 								scanner.SeverityWarning,
 							),
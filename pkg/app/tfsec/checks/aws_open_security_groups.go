@@ -35,12 +35,12 @@ func init() {
 						return nil
 					}
 
-					for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+					for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 						if strings.HasSuffix(cidr.AsString(), "/0") {
 							results = append(results,
 								check.NewResult(
 									fmt.Sprintf("Resource '%s' defines a fully open ingress security group.", block.Name()),
-									cidrBlocksAttr.Range(),
+									cidrBlocksAttr.RangeOfElement(i),
 									scanner.SeverityWarning,
 								),
 							)
@@ -53,12 +53,12 @@ func init() {
 						return nil
 					}
 
-					for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+					for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 						if strings.HasSuffix(cidr.AsString(), "/0") {
 							results = append(results,
 								check.NewResult(
 									fmt.Sprintf("Resource '%s' defines a fully open ingress security group.", block.Name()),
-									cidrBlocksAttr.Range(),
+									cidrBlocksAttr.RangeOfElement(i),
 									scanner.SeverityWarning,
 								),
 							)
@@ -88,12 +88,12 @@ func init() {
 						return nil
 					}
 
-					for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+					for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 						if strings.HasSuffix(cidr.AsString(), "/0") {
 							results = append(results,
 								check.NewResultWithValueAnnotation(
 									fmt.Sprintf("Resource '%s' defines a fully open egress security group.", block.Name()),
-									cidrBlocksAttr.Range(),
+									cidrBlocksAttr.RangeOfElement(i),
 									cidrBlocksAttr,
 									scanner.SeverityWarning,
 								),
@@ -107,12 +107,12 @@ func init() {
 						return nil
 					}
 
-					for _, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
+					for i, cidr := range cidrBlocksAttr.Value().AsValueSlice() {
 						if strings.HasSuffix(cidr.AsString(), "/0") {
 							results = append(results,
 								check.NewResultWithValueAnnotation(
 									fmt.Sprintf("Resource '%s' defines a fully open egress security group.", block.Name()),
-									cidrBlocksAttr.Range(),
+									cidrBlocksAttr.RangeOfElement(i),
 									cidrBlocksAttr,
 									scanner.SeverityWarning,
 								),
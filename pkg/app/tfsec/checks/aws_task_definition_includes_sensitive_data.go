@@ -44,11 +44,12 @@ func init() {
 
 				for _, definition := range definitions {
 					for _, env := range definition.EnvVars {
-						if security.IsSensitiveAttribute(env.Name) && env.Value != "" {
-							results = append(results, check.NewResultWithValueAnnotation(
-								fmt.Sprintf("Resource '%s' includes a potentially sensitive environment variable '%s' in the container definition.", block.Name(), env.Name),
+						if detection, ok := security.DetectSecret(env.Name, env.Value); ok {
+							results = append(results, check.NewSensitiveResult(
+								describeSecret(fmt.Sprintf("Resource '%s' includes a potentially sensitive environment variable '%s' in the container definition.", block.Name(), env.Name), detection),
 								definitionsAttr.Range(),
 								definitionsAttr,
+								env.Value,
 								scanner.SeverityWarning,
 							))
 						}
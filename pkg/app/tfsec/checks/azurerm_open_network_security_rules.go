@@ -55,13 +55,13 @@ func init() {
 			var results []scanner.Result
 
 			if prefixesAttr := block.GetAttribute("source_address_prefixes"); prefixesAttr != nil && prefixesAttr.Value().LengthInt() > 0 {
-				for _, prefix := range prefixesAttr.Value().AsValueSlice() {
+				for i, prefix := range prefixesAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(prefix.AsString(), "/0") || prefix.AsString() == "*" {
 						if accessAttr := block.GetAttribute("access"); accessAttr != nil && accessAttr.Value().AsString() == "Allow" {
 							results = append(results,
 								check.NewResultWithValueAnnotation(
 									fmt.Sprintf("Resource '%s' defines a fully open %s security group rule.", block.Name(), prefix.AsString()),
-									prefixesAttr.Range(),
+									prefixesAttr.RangeOfElement(i),
 									prefixesAttr,
 									scanner.SeverityWarning,
 								),
@@ -111,13 +111,13 @@ func init() {
 			var results []scanner.Result
 
 			if prefixesAttr := block.GetAttribute("destination_address_prefixes"); prefixesAttr != nil && prefixesAttr.Value().LengthInt() > 0 {
-				for _, prefix := range prefixesAttr.Value().AsValueSlice() {
+				for i, prefix := range prefixesAttr.Value().AsValueSlice() {
 					if strings.HasSuffix(prefix.AsString(), "/0") || prefix.AsString() == "*" {
 						if accessAttr := block.GetAttribute("access"); accessAttr != nil && accessAttr.Value().AsString() == "Allow" {
 							results = append(results,
 								check.NewResultWithValueAnnotation(
 									fmt.Sprintf("Resource '%s' defines a fully open %s security group rule.", block.Name(), prefix.AsString()),
-									prefixesAttr.Range(),
+									prefixesAttr.RangeOfElement(i),
 									prefixesAttr,
 									scanner.SeverityWarning,
 								),
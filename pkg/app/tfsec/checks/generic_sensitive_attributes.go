@@ -47,16 +47,17 @@ func init() {
 						continue SKIP
 					}
 				}
-				if security.IsSensitiveAttribute(attribute.Name()) {
-					if attribute.Type() == cty.String && attribute.Value().AsString() != "" {
-						results = append(results, check.NewResultWithValueAnnotation(
-							fmt.Sprintf("Block '%s' includes a potentially sensitive attribute which is defined within the project.", block.Name()),
-							attribute.Range(),
-							attribute,
-							scanner.SeverityWarning,
-						))
-					}
-
+				if attribute.Type() != cty.String || attribute.Value().IsNull() {
+					continue
+				}
+				if detection, ok := security.DetectSecret(attribute.Name(), attribute.Value().AsString()); ok {
+					results = append(results, check.NewSensitiveResult(
+						describeSecret(fmt.Sprintf("Block '%s' includes a potentially sensitive attribute which is defined within the project.", block.Name()), detection),
+						attribute.Range(),
+						attribute,
+						attribute.Value().AsString(),
+						scanner.SeverityWarning,
+					))
 				}
 			}
 
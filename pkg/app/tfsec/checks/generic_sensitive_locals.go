@@ -26,15 +26,17 @@ func init() {
 			var results []scanner.Result
 
 			for _, attribute := range block.GetAttributes() {
-				if security.IsSensitiveAttribute(attribute.Name()) {
-					if attribute.Type() == cty.String && attribute.Value().AsString() != "" {
-						results = append(results, check.NewResultWithValueAnnotation(
-							fmt.Sprintf("Local '%s' includes a potentially sensitive value which is defined within the project.", block.Name()),
-							attribute.Range(),
-							attribute,
-							scanner.SeverityWarning,
-						))
-					}
+				if attribute.Type() != cty.String || attribute.Value().IsNull() {
+					continue
+				}
+				if detection, ok := security.DetectSecret(attribute.Name(), attribute.Value().AsString()); ok {
+					results = append(results, check.NewSensitiveResult(
+						describeSecret(fmt.Sprintf("Local '%s' includes a potentially sensitive value which is defined within the project.", block.Name()), detection),
+						attribute.Range(),
+						attribute,
+						attribute.Value().AsString(),
+						scanner.SeverityWarning,
+					))
 				}
 			}
 
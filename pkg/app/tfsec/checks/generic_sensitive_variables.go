@@ -27,23 +27,20 @@ func init() {
 				return nil
 			}
 
-			if !security.IsSensitiveAttribute(block.Labels()[0]) {
-				return nil
-			}
-
 			var results []scanner.Result
 
 			for _, attribute := range block.GetAttributes() {
 				if attribute.Name() == "default" {
 					val := attribute.Value()
-					if val.Type() != cty.String {
+					if val.Type() != cty.String || val.IsNull() {
 						continue
 					}
-					if val.AsString() != "" {
-						results = append(results, check.NewResultWithValueAnnotation(
-							fmt.Sprintf("Variable '%s' includes a potentially sensitive default value.", block.Name()),
+					if detection, ok := security.DetectSecret(block.Labels()[0], val.AsString()); ok {
+						results = append(results, check.NewSensitiveResult(
+							describeSecret(fmt.Sprintf("Variable '%s' includes a potentially sensitive default value.", block.Name()), detection),
 							attribute.Range(),
 							attribute,
+							val.AsString(),
 							scanner.SeverityWarning,
 						))
 					}
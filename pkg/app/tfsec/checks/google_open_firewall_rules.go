@@ -32,12 +32,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range sourceRanges.Value().AsValueSlice() {
+				for i, cidr := range sourceRanges.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResult(
 								fmt.Sprintf("Resource '%s' defines a fully open inbound firewall rule.", block.Name()),
-								sourceRanges.Range(),
+								sourceRanges.RangeOfElement(i),
 								scanner.SeverityWarning,
 							),
 						}
@@ -63,12 +63,12 @@ func init() {
 					return nil
 				}
 
-				for _, cidr := range destinationRanges.Value().AsValueSlice() {
+				for i, cidr := range destinationRanges.Value().AsValueSlice() {
 					if strings.HasSuffix(cidr.AsString(), "/0") {
 						return []scanner.Result{
 							check.NewResult(
 								fmt.Sprintf("Resource '%s' defines a fully open outbound firewall rule.", block.Name()),
-								destinationRanges.Range(),
+								destinationRanges.RangeOfElement(i),
 								scanner.SeverityWarning,
 							),
 						}
@@ -1,7 +1,11 @@
 package checks
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/security"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -17,3 +21,8 @@ func isBooleanOrStringTrue(val *parser.Attribute) bool {
 		return false
 	}
 }
+
+// describeSecret adds the detector which recognised a secret to the description of a result
+func describeSecret(description string, detection security.Detection) string {
+	return fmt.Sprintf("%s (detected %s).", strings.TrimSuffix(description, "."), detection.Description)
+}
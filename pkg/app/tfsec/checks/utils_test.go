@@ -41,9 +41,9 @@ func Test_isBooleanOrStringTrue(t *testing.T) {
 	}
 
 	for _, test := range tests {
-		expr, _ := hclsyntax.ParseExpression([]byte(test.rawExpr), "", hcl.Pos{0, 0, 0})
+		expr, _ := hclsyntax.ParseExpression([]byte(test.rawExpr), "", hcl.Pos{Line: 0, Column: 0, Byte: 0})
 		attr := parser.NewAttribute(
-			&hclsyntax.Attribute{
+			&hcl.Attribute{
 				Expr: expr,
 			},
 			nil,
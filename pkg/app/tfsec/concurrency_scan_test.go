@@ -0,0 +1,69 @@
+package tfsec
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_ConcurrentScanMatchesSequentialScan(t *testing.T) {
+
+	var source strings.Builder
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&source, `
+resource "aws_s3_bucket" "bucket_%d" {
+	acl = "public-read"
+}
+
+resource "aws_security_group_rule" "rule_%d" {
+	type        = "ingress"
+	cidr_blocks = ["0.0.0.0/0"]
+}
+`, i, i)
+	}
+
+	blocks := createBlocksFromSource(source.String())
+
+	sequential := scanner.New(scanner.OptionWithConcurrency(1)).Scan(blocks, excludedChecksList)
+	require.NotEmpty(t, sequential)
+
+	for _, concurrency := range []int{2, 8, 0} {
+		assert.Equal(t, sequential, scanner.New(scanner.OptionWithConcurrency(concurrency)).Scan(blocks, excludedChecksList))
+	}
+
+	assert.True(t, sort.SliceIsSorted(sequential, func(i, j int) bool {
+		return sequential[i].Range.StartLine < sequential[j].Range.StartLine
+	}))
+}
+
+func Test_ChecksAreOnlyRunForTheirBlockTypesAndLabels(t *testing.T) {
+
+	results := scanSource(`
+data "aws_s3_bucket" "existing" {
+	acl = "public-read"
+}
+
+resource "aws_s3_bucket_object" "object" {
+	acl = "public-read"
+}
+
+resource "aws_s3_bucket" "bucket" {
+	acl = "public-read"
+}
+`)
+
+	var addresses []string
+	for _, result := range results {
+		if result.RuleID == checks.AWSBadBucketACL {
+			addresses = append(addresses, result.Address)
+		}
+	}
+	assert.Equal(t, []string{"aws_s3_bucket.bucket"}, addresses)
+}
@@ -0,0 +1,211 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+	"gopkg.in/yaml.v2"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// Filenames are the names of configuration files, in the order they are looked for in each directory
+var Filenames = []string{".tfsec.yml", ".tfsec.yaml"}
+
+// Config holds the scan settings from a configuration file. Paths are made absolute, relative to the directory of the
+// file they were read from.
+type Config struct {
+	Exclude           []string                    `yaml:"exclude"`
+	Include           []string                    `yaml:"include"`
+	SeverityOverrides map[string]scanner.Severity `yaml:"severity_overrides"`
+	MinimumSeverity   scanner.Severity            `yaml:"minimum_severity"`
+	ExcludePaths      []string                    `yaml:"exclude_paths"`
+	TFVarsFiles       []string                    `yaml:"tfvars_files"`
+	Format            string                      `yaml:"format"`
+	CustomCheckDirs   []string                    `yaml:"custom_check_dirs"`
+	// dir is the directory the configuration applies to, which exclude_paths are matched relative to
+	dir string
+}
+
+// Load reads the configuration file at the given path
+func Load(path string) (*Config, error) {
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config Config
+	if err := yaml.UnmarshalStrict(data, &config); err != nil {
+		return nil, fmt.Errorf("%s: failed to load configuration: %s", path, err)
+	}
+	config.dir = filepath.Dir(path)
+
+	if err := config.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+
+	for i, tfvarsFile := range config.TFVarsFiles {
+		config.TFVarsFiles[i] = config.resolve(tfvarsFile)
+	}
+	for i, checkDir := range config.CustomCheckDirs {
+		config.CustomCheckDirs[i] = config.resolve(checkDir)
+	}
+
+	return &config, nil
+}
+
+// FromFlags creates the configuration given with command line flags, which takes precedence over any configuration
+// file. Severities may be given in any case, and each severity override is in the form CODE=SEVERITY e.g.
+// AWS018=ERROR.
+func FromFlags(format string, minimumSeverity string, severityOverrides []string) (*Config, error) {
+	config := &Config{
+		Format:          format,
+		MinimumSeverity: scanner.Severity(strings.ToUpper(minimumSeverity)),
+	}
+	if config.MinimumSeverity != "" && !config.MinimumSeverity.IsValid() {
+		return nil, fmt.Errorf("invalid minimum severity specified: '%s' (expected ERROR, WARNING or INFO)", minimumSeverity)
+	}
+	for _, override := range severityOverrides {
+		parts := strings.SplitN(override, "=", 2)
+		if len(parts) != 2 || parts[0] == "" || !scanner.Severity(strings.ToUpper(parts[1])).IsValid() {
+			return nil, fmt.Errorf("invalid severity override specified: '%s' (expected CODE=ERROR, CODE=WARNING or CODE=INFO)", override)
+		}
+		if config.SeverityOverrides == nil {
+			config.SeverityOverrides = make(map[string]scanner.Severity)
+		}
+		config.SeverityOverrides[parts[0]] = scanner.Severity(strings.ToUpper(parts[1]))
+	}
+	return config, nil
+}
+
+func (config *Config) validate() error {
+	for code, severity := range config.SeverityOverrides {
+		if !severity.IsValid() {
+			return fmt.Errorf("invalid severity '%s' for %s (expected ERROR, WARNING or INFO)", severity, code)
+		}
+	}
+	if config.MinimumSeverity != "" && !config.MinimumSeverity.IsValid() {
+		return fmt.Errorf("invalid minimum severity '%s' (expected ERROR, WARNING or INFO)", config.MinimumSeverity)
+	}
+	for _, pattern := range config.ExcludePaths {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid exclude path '%s': %s", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (config *Config) resolve(path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(config.dir, path)
+}
+
+// Merge returns the combination of the configuration with an overriding one, such as that of a subdirectory. Each
+// setting of the overriding configuration replaces the same setting of this one, except for severity overrides,
+// which are combined.
+func (config *Config) Merge(override *Config) *Config {
+
+	merged := *config
+	merged.SeverityOverrides = make(map[string]scanner.Severity)
+	for code, severity := range config.SeverityOverrides {
+		merged.SeverityOverrides[code] = severity
+	}
+
+	if override == nil {
+		return &merged
+	}
+
+	for code, severity := range override.SeverityOverrides {
+		merged.SeverityOverrides[code] = severity
+	}
+	if override.Exclude != nil {
+		merged.Exclude = override.Exclude
+	}
+	if override.Include != nil {
+		merged.Include = override.Include
+	}
+	if override.MinimumSeverity != "" {
+		merged.MinimumSeverity = override.MinimumSeverity
+	}
+	if override.ExcludePaths != nil {
+		merged.ExcludePaths = override.ExcludePaths
+		merged.dir = override.dir
+	}
+	if override.TFVarsFiles != nil {
+		merged.TFVarsFiles = override.TFVarsFiles
+	}
+	if override.Format != "" {
+		merged.Format = override.Format
+	}
+	if override.CustomCheckDirs != nil {
+		merged.CustomCheckDirs = override.CustomCheckDirs
+	}
+
+	return &merged
+}
+
+// IsPathExcluded returns true if the given file matches one of the exclude_paths globs, or is within a directory
+// which does
+func (config *Config) IsPathExcluded(path string) bool {
+	if config.dir == "" || len(config.ExcludePaths) == 0 {
+		return false
+	}
+	relative, err := filepath.Rel(config.dir, path)
+	if err != nil || strings.HasPrefix(relative, "..") {
+		return false
+	}
+	relative = filepath.ToSlash(relative)
+	for _, pattern := range config.ExcludePaths {
+		pattern = strings.TrimSuffix(filepath.ToSlash(pattern), "/")
+		for candidate := relative; candidate != "."; candidate = filepath.ToSlash(filepath.Dir(candidate)) {
+			if matched, _ := doublestar.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IsCheckEnabled returns true if results of the given check should be reported
+func (config *Config) IsCheckEnabled(code scanner.RuleID) bool {
+	for _, excluded := range config.Exclude {
+		if excluded == string(code) {
+			return false
+		}
+	}
+	if len(config.Include) == 0 {
+		return true
+	}
+	for _, included := range config.Include {
+		if included == string(code) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the result with its severity overridden as configured, and false if the result should not be
+// reported at all
+func (config *Config) Apply(result scanner.Result) (scanner.Result, bool) {
+	if config.IsPathExcluded(result.Range.Filename) || !config.IsCheckEnabled(result.RuleID) {
+		return result, false
+	}
+	if severity, overridden := config.SeverityOverrides[string(result.RuleID)]; overridden {
+		result.Severity = severity
+	}
+	if config.MinimumSeverity != "" && !result.Severity.IsAtLeast(config.MinimumSeverity) {
+		return result, false
+	}
+	return result, true
+}
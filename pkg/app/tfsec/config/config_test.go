@@ -0,0 +1,282 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func createTestDirectory(files map[string]string) string {
+	dir, err := ioutil.TempDir(os.TempDir(), "tfsec")
+	if err != nil {
+		panic(err)
+	}
+	for filename, contents := range files {
+		path := filepath.Join(dir, filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			panic(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+			panic(err)
+		}
+	}
+	return dir
+}
+
+func resultIn(path string, code scanner.RuleID, severity scanner.Severity) scanner.Result {
+	return scanner.Result{
+		RuleID:   code,
+		Severity: severity,
+		Range:    parser.Range{Filename: path, StartLine: 1, EndLine: 1},
+	}
+}
+
+func Test_ConfigIsFoundAboveScannedDirectory(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml": `
+exclude: [AWS001]
+format: json
+tfvars_files: [prod.tfvars]
+severity_overrides:
+  AWS018: ERROR
+`,
+		"infra/.tfsec.yml": `
+exclude: [AWS002]
+severity_overrides:
+  AWS006: INFO
+`,
+		"infra/main.tf": ``,
+	})
+
+	tree, err := LoadTree(filepath.Join(dir, "infra"), "", nil, nil)
+	require.NoError(t, err)
+
+	base := tree.Base()
+	assert.Equal(t, "json", base.Format)
+	assert.Equal(t, []string{filepath.Join(dir, "prod.tfvars")}, base.TFVarsFiles)
+	assert.Equal(t, []string{"AWS002"}, base.Exclude)
+	assert.Equal(t, scanner.SeverityError, base.SeverityOverrides["AWS018"])
+	assert.Equal(t, scanner.SeverityInfo, base.SeverityOverrides["AWS006"])
+}
+
+func Test_SubdirectoryConfigOverridesParent(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml": `
+exclude: [AWS001]
+minimum_severity: WARNING
+`,
+		"legacy/.tfsec.yml": `
+exclude: [AWS002]
+severity_overrides:
+  AWS003: INFO
+minimum_severity: INFO
+`,
+	})
+
+	tree, err := LoadTree(dir, "", nil, nil)
+	require.NoError(t, err)
+
+	results := tree.Apply([]scanner.Result{
+		resultIn(filepath.Join(dir, "main.tf"), "AWS001", scanner.SeverityError),
+		resultIn(filepath.Join(dir, "main.tf"), "AWS002", scanner.SeverityError),
+		resultIn(filepath.Join(dir, "main.tf"), "AWS003", scanner.SeverityInfo),
+		resultIn(filepath.Join(dir, "legacy", "main.tf"), "AWS001", scanner.SeverityError),
+		resultIn(filepath.Join(dir, "legacy", "main.tf"), "AWS002", scanner.SeverityError),
+		resultIn(filepath.Join(dir, "legacy", "main.tf"), "AWS003", scanner.SeverityError),
+	})
+
+	require.Len(t, results, 3)
+	assert.Equal(t, scanner.RuleID("AWS002"), results[0].RuleID)
+	assert.Equal(t, scanner.RuleID("AWS001"), results[1].RuleID)
+	assert.Equal(t, scanner.RuleID("AWS003"), results[2].RuleID)
+	assert.Equal(t, scanner.SeverityInfo, results[2].Severity)
+}
+
+func Test_ExplicitConfigReplacesConfigAboveScannedDirectory(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml":      `format: json`,
+		"ci/scan.yml":     `format: checkstyle`,
+		"modules/main.tf": ``,
+	})
+
+	tree, err := LoadTree(dir, filepath.Join(dir, "ci", "scan.yml"), nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "checkstyle", tree.Base().Format)
+}
+
+func Test_OverrideConfigTakesPrecedence(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml": `
+format: json
+minimum_severity: ERROR
+`,
+		"legacy/.tfsec.yml": `minimum_severity: INFO`,
+	})
+
+	tree, err := LoadTree(dir, "", &Config{Format: "csv", MinimumSeverity: scanner.SeverityWarning}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "csv", tree.Base().Format)
+	assert.Equal(t, scanner.SeverityWarning, tree.For(filepath.Join(dir, "legacy", "main.tf")).MinimumSeverity)
+}
+
+func Test_ConfigInExcludedDirectoryIsNotRead(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"vendor/.tfsec.yml": `minimum_severity: LOW`,
+		"legacy/.tfsec.yml": `exclude: [AWS001]`,
+	})
+
+	tree, err := LoadTree(dir, "", nil, []string{filepath.Join(dir, "vendor"), filepath.Join(dir, "legacy")})
+	require.NoError(t, err)
+	assert.True(t, tree.For(filepath.Join(dir, "legacy", "main.tf")).IsCheckEnabled("AWS001"))
+}
+
+func Test_SubdirectoryTFVarsFilesApplyToRootModulesWithinIt(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml":           `tfvars_files: [default.tfvars]`,
+		"envs/prod/.tfsec.yml": `tfvars_files: [prod.tfvars]`,
+	})
+
+	tree, err := LoadTree(dir, "", nil, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "default.tfvars")}, tree.TFVarsFiles(dir))
+	assert.Equal(t, []string{filepath.Join(dir, "default.tfvars")}, tree.TFVarsFiles(filepath.Join(dir, "envs", "dev")))
+	assert.Equal(t, []string{filepath.Join(dir, "envs", "prod", "prod.tfvars")}, tree.TFVarsFiles(filepath.Join(dir, "envs", "prod")))
+	assert.Equal(t, []string{filepath.Join(dir, "envs", "prod", "prod.tfvars")}, tree.TFVarsFiles(filepath.Join(dir, "envs", "prod", "eu")))
+}
+
+func Test_ExcludePaths(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		".tfsec.yml": `
+exclude_paths:
+  - modules/legacy
+  - "**/*_test.tf"
+`,
+	})
+
+	tree, err := LoadTree(dir, "", nil, nil)
+	require.NoError(t, err)
+
+	config := tree.For(filepath.Join(dir, "main.tf"))
+	assert.False(t, config.IsPathExcluded(filepath.Join(dir, "main.tf")))
+	assert.True(t, config.IsPathExcluded(filepath.Join(dir, "modules", "legacy", "main.tf")))
+	assert.True(t, config.IsPathExcluded(filepath.Join(dir, "modules", "legacy", "nested", "main.tf")))
+	assert.False(t, config.IsPathExcluded(filepath.Join(dir, "modules", "current", "main.tf")))
+	assert.True(t, config.IsPathExcluded(filepath.Join(dir, "envs", "prod", "network_test.tf")))
+	assert.True(t, tree.IsPathExcluded(filepath.Join(dir, "modules", "legacy")))
+	assert.False(t, tree.IsPathExcluded(filepath.Join(dir, "modules")))
+}
+
+func Test_IncludedChecks(t *testing.T) {
+
+	config := &Config{Include: []string{"AWS001", "AWS002"}, Exclude: []string{"AWS002"}}
+	assert.True(t, config.IsCheckEnabled("AWS001"))
+	assert.False(t, config.IsCheckEnabled("AWS002"))
+	assert.False(t, config.IsCheckEnabled("AWS003"))
+}
+
+func Test_InvalidConfig(t *testing.T) {
+
+	var tests = []struct {
+		name   string
+		source string
+	}{
+		{name: "unknown setting", source: `exclude_checks: [AWS001]`},
+		{name: "invalid severity override", source: "severity_overrides:\n  AWS001: CRITICAL"},
+		{name: "invalid minimum severity", source: `minimum_severity: LOW`},
+		{name: "invalid exclude path", source: `exclude_paths: ["[a-"]`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := createTestDirectory(map[string]string{".tfsec.yml": test.source})
+			_, err := LoadTree(dir, "", nil, nil)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_ConfigFromFlags(t *testing.T) {
+
+	var tests = []struct {
+		name              string
+		minimumSeverity   string
+		severityOverrides []string
+		expected          *Config
+		expectedError     string
+	}{
+		{
+			name:     "no flags",
+			expected: &Config{Format: "json"},
+		},
+		{
+			name:              "severities in any case",
+			minimumSeverity:   "warning",
+			severityOverrides: []string{"AWS018=error", "GCP010=Info"},
+			expected: &Config{
+				Format:            "json",
+				MinimumSeverity:   scanner.SeverityWarning,
+				SeverityOverrides: map[string]scanner.Severity{"AWS018": scanner.SeverityError, "GCP010": scanner.SeverityInfo},
+			},
+		},
+		{
+			name:              "later override of the same check wins",
+			severityOverrides: []string{"AWS018=ERROR", "AWS018=INFO"},
+			expected: &Config{
+				Format:            "json",
+				SeverityOverrides: map[string]scanner.Severity{"AWS018": scanner.SeverityInfo},
+			},
+		},
+		{
+			name:            "unknown minimum severity",
+			minimumSeverity: "LOW",
+			expectedError:   "invalid minimum severity specified: 'LOW' (expected ERROR, WARNING or INFO)",
+		},
+		{
+			name:              "unknown override severity",
+			severityOverrides: []string{"AWS018=CRITICAL"},
+			expectedError:     "invalid severity override specified: 'AWS018=CRITICAL' (expected CODE=ERROR, CODE=WARNING or CODE=INFO)",
+		},
+		{
+			name:              "override without severity",
+			severityOverrides: []string{"AWS018"},
+			expectedError:     "invalid severity override specified: 'AWS018' (expected CODE=ERROR, CODE=WARNING or CODE=INFO)",
+		},
+		{
+			name:              "override without code",
+			severityOverrides: []string{"=ERROR"},
+			expectedError:     "invalid severity override specified: '=ERROR' (expected CODE=ERROR, CODE=WARNING or CODE=INFO)",
+		},
+		{
+			name:              "override with empty severity",
+			severityOverrides: []string{"AWS018="},
+			expectedError:     "invalid severity override specified: 'AWS018=' (expected CODE=ERROR, CODE=WARNING or CODE=INFO)",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config, err := FromFlags("json", test.minimumSeverity, test.severityOverrides)
+			if test.expectedError != "" {
+				assert.EqualError(t, err, test.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expected, config)
+		})
+	}
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// Tree holds the configuration of a scanned directory, along with the configuration files of any of its
+// subdirectories, each of which overrides the configuration of the directories above it for the files within it
+type Tree struct {
+	base    *Config
+	subdirs map[string]*Config
+	cli     *Config
+}
+
+// LoadTree loads the configuration for a scan of the given directory. The configuration of the directory is read from
+// the given file if one is set, or otherwise from the configuration files in the directory and those above it, with
+// the nearest taking precedence. Settings in the override configuration, usually from command line flags, take
+// precedence over all configuration files. Configuration files within the excluded directories are not read.
+func LoadTree(dir string, configFile string, override *Config, excludedDirectories []string) (*Tree, error) {
+
+	tree := &Tree{
+		base:    &Config{dir: dir},
+		subdirs: make(map[string]*Config),
+		cli:     override,
+	}
+
+	if configFile != "" {
+		config, err := Load(configFile)
+		if err != nil {
+			return nil, err
+		}
+		tree.base = tree.base.Merge(config)
+	} else {
+		for _, path := range findAbove(dir) {
+			config, err := Load(path)
+			if err != nil {
+				return nil, err
+			}
+			tree.base = tree.base.Merge(config)
+		}
+	}
+
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() || path == dir {
+			return nil
+		}
+		switch info.Name() {
+		case ".terraform", ".git":
+			return filepath.SkipDir
+		}
+		for _, excluded := range excludedDirectories {
+			if path == excluded {
+				return filepath.SkipDir
+			}
+		}
+		if configPath, found := findIn(path); found {
+			config, err := Load(configPath)
+			if err != nil {
+				return err
+			}
+			tree.subdirs[path] = config
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return tree, nil
+}
+
+// findAbove returns the configuration files in the given directory and each directory above it, furthest first
+func findAbove(dir string) []string {
+	var paths []string
+	for {
+		if path, found := findIn(dir); found {
+			paths = append([]string{path}, paths...)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return paths
+		}
+		dir = parent
+	}
+}
+
+func findIn(dir string) (string, bool) {
+	for _, filename := range Filenames {
+		path := filepath.Join(dir, filename)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// Base returns the configuration of the scanned directory itself, which settings for the whole scan, such as the
+// output format, are taken from
+func (tree *Tree) Base() *Config {
+	return tree.base.Merge(tree.cli)
+}
+
+// For returns the configuration which applies to the given file or directory
+func (tree *Tree) For(path string) *Config {
+	var dirs []string
+	for dir := range tree.subdirs {
+		if path == dir || strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			dirs = append(dirs, dir)
+		}
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		return len(dirs[i]) < len(dirs[j])
+	})
+	config := tree.base
+	for _, dir := range dirs {
+		config = config.Merge(tree.subdirs[dir])
+	}
+	return config.Merge(tree.cli)
+}
+
+// TFVarsFiles returns the tfvars files configured for the root module in the given directory
+func (tree *Tree) TFVarsFiles(rootModule string) []string {
+	return tree.For(rootModule).TFVarsFiles
+}
+
+// IsPathExcluded returns true if the given file or directory is excluded by the exclude_paths of the configuration
+// which applies to it, so shouldn't be parsed at all
+func (tree *Tree) IsPathExcluded(path string) bool {
+	return tree.For(path).IsPathExcluded(path)
+}
+
+// Apply applies the configuration of each result's file to the results, returning only those which should be reported
+func (tree *Tree) Apply(results []scanner.Result) []scanner.Result {
+	var applied []scanner.Result
+	for _, result := range results {
+		if result, report := tree.For(result.Range.Filename).Apply(result); report {
+			applied = append(applied, result)
+		}
+	}
+	return applied
+}
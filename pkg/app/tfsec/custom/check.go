@@ -0,0 +1,194 @@
+package custom
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hashicorp/hcl/v2"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// Action is the comparison a MatchSpec makes against an attribute or nested block
+type Action string
+
+const (
+	ActionPresent            Action = "present"
+	ActionAbsent             Action = "absent"
+	ActionEquals             Action = "equals"
+	ActionRegex              Action = "regex"
+	ActionContains           Action = "contains"
+	ActionGreaterThan        Action = "greaterThan"
+	ActionGreaterThanOrEqual Action = "greaterThanOrEqual"
+	ActionLessThan           Action = "lessThan"
+	ActionLessThanOrEqual    Action = "lessThanOrEqual"
+	ActionAnd                Action = "and"
+	ActionOr                 Action = "or"
+	ActionNot                Action = "not"
+)
+
+// ChecksFile is the content of a file of custom checks
+type ChecksFile struct {
+	Checks []*Check `json:"checks" yaml:"checks"`
+}
+
+// Check is a check declared in a custom checks file. A result is reported for each block of the required types and
+// labels which does not satisfy the check's match, or for which the check's HCL expression is not true.
+type Check struct {
+	Code           scanner.RuleID       `json:"code" yaml:"code"`
+	Description    string               `json:"description" yaml:"description"`
+	Provider       scanner.RuleProvider `json:"provider" yaml:"provider"`
+	Severity       scanner.Severity     `json:"severity" yaml:"severity"`
+	RequiredTypes  []string             `json:"requiredTypes" yaml:"requiredTypes"`
+	RequiredLabels []string             `json:"requiredLabels" yaml:"requiredLabels"`
+	ErrorMessage   string               `json:"errorMessage" yaml:"errorMessage"`
+	Link           string               `json:"link" yaml:"link"`
+	Match          *MatchSpec           `json:"match" yaml:"match"`
+	Expression     string               `json:"expression" yaml:"expression"`
+	filename       string
+	expression     hcl.Expression
+}
+
+// MatchSpec is a condition which a block must satisfy. Name is the attribute or nested block the action applies to.
+// SubMatch is applied to every nested block called Name, and Predicates are the conditions combined by and, or and
+// not.
+type MatchSpec struct {
+	Name       string       `json:"name" yaml:"name"`
+	Action     Action       `json:"action" yaml:"action"`
+	Value      interface{}  `json:"value" yaml:"value"`
+	SubMatch   *MatchSpec   `json:"subMatch" yaml:"subMatch"`
+	Predicates []*MatchSpec `json:"predicates" yaml:"predicates"`
+	expression *regexp.Regexp
+}
+
+// validate checks the custom check is complete, and compiles any regular expressions it uses
+func (check *Check) validate() error {
+	if check.Code == "" {
+		return fmt.Errorf("%s: custom check has no code", check.filename)
+	}
+	if check.Description == "" {
+		return fmt.Errorf("%s: custom check %s has no description", check.filename, check.Code)
+	}
+	if check.Severity == "" {
+		check.Severity = scanner.SeverityWarning
+	}
+	if !check.Severity.IsValid() {
+		return fmt.Errorf("%s: custom check %s has an invalid severity '%s'", check.filename, check.Code, check.Severity)
+	}
+	if check.Provider == "" {
+		check.Provider = scanner.GeneralProvider
+	}
+	if check.Match != nil && check.Expression != "" {
+		return fmt.Errorf("%s: custom check %s has both a match and an expression", check.filename, check.Code)
+	}
+	if check.Expression != "" {
+		expression, err := parseExpression(check.Expression, check.filename)
+		if err != nil {
+			return fmt.Errorf("%s: custom check %s: %s", check.filename, check.Code, err)
+		}
+		check.expression = expression
+		return nil
+	}
+	if check.Match == nil {
+		return fmt.Errorf("%s: custom check %s has no match or expression", check.filename, check.Code)
+	}
+	if err := check.Match.validate(); err != nil {
+		return fmt.Errorf("%s: custom check %s: %s", check.filename, check.Code, err)
+	}
+	return nil
+}
+
+func (spec *MatchSpec) validate() error {
+	switch spec.Action {
+	case ActionAnd, ActionOr, ActionNot:
+		if len(spec.Predicates) == 0 {
+			return fmt.Errorf("'%s' requires predicates", spec.Action)
+		}
+		if spec.Action == ActionNot && len(spec.Predicates) != 1 {
+			return fmt.Errorf("'not' requires exactly one predicate")
+		}
+		for _, predicate := range spec.Predicates {
+			if err := predicate.validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ActionPresent, ActionAbsent:
+	case ActionEquals, ActionContains:
+		if spec.Value == nil {
+			return fmt.Errorf("'%s' of '%s' requires a value", spec.Action, spec.Name)
+		}
+	case ActionRegex:
+		pattern, ok := spec.Value.(string)
+		if !ok {
+			return fmt.Errorf("'regex' of '%s' requires a string value", spec.Name)
+		}
+		expression, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid regex for '%s': %s", spec.Name, err)
+		}
+		spec.expression = expression
+	case ActionGreaterThan, ActionGreaterThanOrEqual, ActionLessThan, ActionLessThanOrEqual:
+		if _, ok := toFloat(spec.Value); !ok {
+			return fmt.Errorf("'%s' of '%s' requires a numeric value", spec.Action, spec.Name)
+		}
+	default:
+		return fmt.Errorf("unknown action '%s'", spec.Action)
+	}
+	if spec.Name == "" {
+		return fmt.Errorf("'%s' requires a name", spec.Action)
+	}
+	if spec.SubMatch != nil {
+		if spec.Action != ActionPresent {
+			return fmt.Errorf("a subMatch of '%s' can only be used with 'present'", spec.Name)
+		}
+		return spec.SubMatch.validate()
+	}
+	return nil
+}
+
+// scannerCheck converts the custom check into a check which is run by the scanner alongside the built in checks
+func (check *Check) scannerCheck() scanner.Check {
+	return scanner.Check{
+		Code:           check.Code,
+		Description:    scanner.RuleDescription(check.Description),
+		Provider:       check.Provider,
+		RequiredTypes:  check.RequiredTypes,
+		RequiredLabels: check.RequiredLabels,
+		Link:           check.Link,
+		CheckFunc: func(c *scanner.Check, block *parser.Block, context *scanner.Context) []scanner.Result {
+			message := check.ErrorMessage
+			if message == "" {
+				message = check.Description
+			}
+			description := fmt.Sprintf("%s: %s", block.Name(), message)
+
+			if check.expression != nil {
+				functions := context.Functions(filepath.Dir(block.Range().Filename))
+				if evaluateExpression(check.expression, block, functions) {
+					return nil
+				}
+				r, attribute := referencedRange(check.expression, block)
+				return []scanner.Result{
+					c.NewResultWithValueAnnotation(description, r, attribute, check.Severity),
+				}
+			}
+
+			if check.Match.matches(block) {
+				return nil
+			}
+			if check.Match.Name != "" {
+				if attribute := block.GetAttribute(check.Match.Name); attribute != nil {
+					return []scanner.Result{
+						c.NewResultWithValueAnnotation(description, attribute.Range(), attribute, check.Severity),
+					}
+				}
+			}
+			return []scanner.Result{
+				c.NewResult(description, block.Range(), check.Severity),
+			}
+		},
+	}
+}
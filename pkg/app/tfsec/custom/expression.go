@@ -0,0 +1,73 @@
+package custom
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+)
+
+// parseExpression parses the HCL expression of a custom check, which may only refer to the block it is applied to, as
+// self
+func parseExpression(source string, filename string) (hcl.Expression, error) {
+	expression, diagnostics := hclsyntax.ParseExpression([]byte(source), filename, hcl.Pos{Line: 1, Column: 1, Byte: 0})
+	if diagnostics.HasErrors() {
+		return nil, fmt.Errorf("invalid expression: %s", diagnostics.Error())
+	}
+	for _, traversal := range expression.Variables() {
+		if traversal.RootName() != "self" {
+			return nil, fmt.Errorf("invalid expression: '%s' is not available, only self can be referred to", traversal.RootName())
+		}
+	}
+	return expression, nil
+}
+
+// evaluateExpression returns true if the expression is true for the given block. An expression which can't be
+// evaluated, such as one referring to an attribute which isn't set, is false; use try() or can() to allow for these.
+// An expression which depends on values which can't be known until terraform is applied is assumed to be true.
+func evaluateExpression(expression hcl.Expression, block *parser.Block, functions map[string]function.Function) bool {
+	ctx := &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"self": block.Values(),
+		},
+		Functions: functions,
+	}
+	value, diagnostics := expression.Value(ctx)
+	if diagnostics.HasErrors() {
+		return false
+	}
+	if !value.IsKnown() {
+		return true
+	}
+	value, err := convert.Convert(value, cty.Bool)
+	if err != nil || value.IsNull() {
+		return false
+	}
+	return value.True()
+}
+
+// referencedRange returns the range of the first attribute or nested block of the given block which the expression
+// refers to, or the range of the whole block if it doesn't refer to any
+func referencedRange(expression hcl.Expression, block *parser.Block) (parser.Range, *parser.Attribute) {
+	for _, traversal := range expression.Variables() {
+		if len(traversal) < 2 {
+			continue
+		}
+		step, ok := traversal[1].(hcl.TraverseAttr)
+		if !ok {
+			continue
+		}
+		if attribute := block.GetAttribute(step.Name); attribute != nil {
+			return attribute.Range(), attribute
+		}
+		if nested := block.GetBlock(step.Name); nested != nil {
+			return nested.Range(), nil
+		}
+	}
+	return block.Range(), nil
+}
@@ -0,0 +1,112 @@
+package custom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// DefaultCheckDir is the directory, within the scanned directory, which custom checks are loaded from by default
+const DefaultCheckDir = ".tfsec"
+
+// LoadCheckDir loads the custom checks declared in each YAML (.yaml, .yml) or JSON (.json) file in the given directory
+func LoadCheckDir(dir string) ([]*Check, error) {
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var filenames []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".yaml", ".yml", ".json":
+			filenames = append(filenames, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(filenames)
+
+	var checks []*Check
+	for _, filename := range filenames {
+		fileChecks, err := LoadCheckFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		checks = append(checks, fileChecks...)
+	}
+
+	return checks, nil
+}
+
+// LoadCheckFile loads the custom checks declared in the given YAML or JSON file
+func LoadCheckFile(filename string) ([]*Check, error) {
+
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var file ChecksFile
+	if strings.ToLower(filepath.Ext(filename)) == ".json" {
+		err = json.Unmarshal(data, &file)
+	} else {
+		err = yaml.UnmarshalStrict(data, &file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to load custom checks: %s", filename, err)
+	}
+
+	for _, check := range file.Checks {
+		check.filename = filename
+		if err := check.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return file.Checks, nil
+}
+
+// RegisterChecks registers the given custom checks with the scanner, so they are run exactly like the built in checks
+func RegisterChecks(checks []*Check) error {
+
+	codes := make(map[scanner.RuleID]bool)
+	for _, registered := range scanner.GetRegisteredChecks() {
+		codes[registered.Code] = true
+	}
+	for _, check := range checks {
+		if codes[check.Code] {
+			return fmt.Errorf("%s: a check already exists with code '%s'", check.filename, check.Code)
+		}
+		codes[check.Code] = true
+	}
+
+	for _, check := range checks {
+		scanner.RegisterCheck(check.scannerCheck())
+	}
+
+	return nil
+}
+
+// LoadAndRegisterCheckDir loads the custom checks in the given directory and registers them with the scanner. Nothing
+// is loaded if the directory does not exist.
+func LoadAndRegisterCheckDir(dir string) error {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	}
+	checks, err := LoadCheckDir(dir)
+	if err != nil {
+		return err
+	}
+	return RegisterChecks(checks)
+}
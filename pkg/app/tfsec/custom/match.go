@@ -0,0 +1,199 @@
+package custom
+
+import (
+	"math/big"
+	"strings"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// matches returns true if the block satisfies the spec. Conditions on values which can't be known until terraform is
+// applied, such as the ID of another resource, are assumed to be satisfied, so that they don't cause false positives.
+func (spec *MatchSpec) matches(block *parser.Block) bool {
+
+	switch spec.Action {
+	case ActionAnd:
+		for _, predicate := range spec.Predicates {
+			if !predicate.matches(block) {
+				return false
+			}
+		}
+		return true
+	case ActionOr:
+		for _, predicate := range spec.Predicates {
+			if predicate.matches(block) {
+				return true
+			}
+		}
+		return false
+	case ActionNot:
+		return !spec.Predicates[0].matches(block)
+	}
+
+	// nested blocks are checked first, as in JSON bodies a nested block can also be read as an attribute
+	nested := block.GetBlocks(spec.Name)
+	attribute := block.GetAttribute(spec.Name)
+
+	switch spec.Action {
+	case ActionPresent:
+		if len(nested) > 0 {
+			if spec.SubMatch != nil {
+				for _, child := range nested {
+					if !spec.SubMatch.matches(child) {
+						return false
+					}
+				}
+			}
+			return true
+		}
+		return attribute != nil && !isKnownNull(attribute.Value())
+	case ActionAbsent:
+		if len(nested) > 0 {
+			return false
+		}
+		return attribute == nil || isKnownNull(attribute.Value())
+	}
+
+	if attribute == nil {
+		return false
+	}
+	value := attribute.Value()
+	if value.Type() == cty.NilType || !value.IsWhollyKnown() {
+		return true
+	}
+	if value.IsNull() {
+		return false
+	}
+
+	switch spec.Action {
+	case ActionEquals:
+		return equals(value, toCty(spec.Value))
+	case ActionRegex:
+		return value.Type() == cty.String && spec.expression.MatchString(value.AsString())
+	case ActionContains:
+		return contains(value, toCty(spec.Value))
+	case ActionGreaterThan, ActionGreaterThanOrEqual, ActionLessThan, ActionLessThanOrEqual:
+		return compare(value, spec.Action, spec.Value)
+	}
+
+	return false
+}
+
+// isKnownNull returns true if a declared attribute is set to null. Values which can't be evaluated, such as references
+// to other resources, are treated as set.
+func isKnownNull(value cty.Value) bool {
+	return value.Type() != cty.NilType && value.IsKnown() && value.IsNull()
+}
+
+// equals returns true if the actual value is equal to the expected value, once converted to the same type
+func equals(actual cty.Value, expected cty.Value) bool {
+	converted, err := convert.Convert(expected, actual.Type())
+	if err != nil {
+		return false
+	}
+	return actual.Equals(converted).True()
+}
+
+// contains returns true if a string contains the expected substring, a list or set contains the expected element, or a
+// map or object has the expected key
+func contains(actual cty.Value, expected cty.Value) bool {
+	actualType := actual.Type()
+	switch {
+	case actualType == cty.String:
+		return expected.Type() == cty.String && strings.Contains(actual.AsString(), expected.AsString())
+	case actualType.IsMapType() || actualType.IsObjectType():
+		if expected.Type() != cty.String {
+			return false
+		}
+		for it := actual.ElementIterator(); it.Next(); {
+			key, _ := it.Element()
+			if key.AsString() == expected.AsString() {
+				return true
+			}
+		}
+		return false
+	case actual.CanIterateElements():
+		for it := actual.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			if !element.IsNull() && equals(element, expected) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// compare returns true if the actual value is a number which satisfies the comparison with the expected value
+func compare(actual cty.Value, action Action, expected interface{}) bool {
+	number, err := convert.Convert(actual, cty.Number)
+	if err != nil {
+		return false
+	}
+	expectedFloat, _ := toFloat(expected)
+	comparison := number.AsBigFloat().Cmp(big.NewFloat(expectedFloat))
+	switch action {
+	case ActionGreaterThan:
+		return comparison > 0
+	case ActionGreaterThanOrEqual:
+		return comparison >= 0
+	case ActionLessThan:
+		return comparison < 0
+	case ActionLessThanOrEqual:
+		return comparison <= 0
+	}
+	return false
+}
+
+// toCty converts a value decoded from YAML or JSON into a cty value
+func toCty(raw interface{}) cty.Value {
+	switch value := raw.(type) {
+	case string:
+		return cty.StringVal(value)
+	case bool:
+		return cty.BoolVal(value)
+	case []interface{}:
+		if len(value) == 0 {
+			return cty.EmptyTupleVal
+		}
+		elements := make([]cty.Value, len(value))
+		for i, element := range value {
+			elements[i] = toCty(element)
+		}
+		return cty.TupleVal(elements)
+	case map[string]interface{}:
+		attributes := make(map[string]cty.Value)
+		for key, element := range value {
+			attributes[key] = toCty(element)
+		}
+		return cty.ObjectVal(attributes)
+	case map[interface{}]interface{}:
+		attributes := make(map[string]cty.Value)
+		for key, element := range value {
+			if name, ok := key.(string); ok {
+				attributes[name] = toCty(element)
+			}
+		}
+		return cty.ObjectVal(attributes)
+	}
+	if number, ok := toFloat(raw); ok {
+		return cty.NumberFloatVal(number)
+	}
+	return cty.NullVal(cty.DynamicPseudoType)
+}
+
+// toFloat converts a number decoded from YAML or JSON into a float
+func toFloat(raw interface{}) (float64, bool) {
+	switch value := raw.(type) {
+	case int:
+		return float64(value), true
+	case int64:
+		return float64(value), true
+	case uint64:
+		return float64(value), true
+	case float64:
+		return value, true
+	}
+	return 0, false
+}
@@ -0,0 +1,402 @@
+package tfsec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/custom"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+const customChecks = `
+checks:
+  - code: CUS001
+    description: Custom buckets must be tagged with a cost centre
+    severity: ERROR
+    requiredTypes: [resource]
+    requiredLabels: [custom_bucket]
+    errorMessage: The CostCentre tag is missing
+    link: https://example.com/standards/tagging
+    match:
+      name: tags
+      action: contains
+      value: CostCentre
+  - code: CUS002
+    description: Custom volumes must be encrypted, with a retention of at least 7 days
+    requiredTypes: [resource]
+    requiredLabels: [custom_volume]
+    match:
+      action: and
+      predicates:
+        - name: encryption
+          action: present
+          subMatch:
+            name: enabled
+            action: equals
+            value: true
+        - name: retention_days
+          action: greaterThanOrEqual
+          value: 7
+  - code: CUS003
+    description: Custom databases must use an approved engine and must not be public
+    requiredTypes: [resource]
+    requiredLabels: [custom_database]
+    match:
+      action: and
+      predicates:
+        - action: or
+          predicates:
+            - name: engine
+              action: regex
+              value: ^postgres(ql)?$
+            - name: engine
+              action: equals
+              value: mysql
+        - action: not
+          predicates:
+            - name: public_ip
+              action: present
+  - code: CUS004
+    description: Custom firewalls must not allow traffic from anywhere
+    requiredTypes: [resource]
+    requiredLabels: [custom_firewall]
+    expression: '!contains(self.cidr_blocks, "0.0.0.0/0")'
+  - code: CUS005
+    description: Custom stores must configure encryption with a customer managed key
+    requiredTypes: [resource]
+    requiredLabels: [custom_store]
+    expression: try(self.encryption[0].key_id, null) != null
+  - code: CUS006
+    description: Custom queues must be encrypted with a customer managed key
+    requiredTypes: [resource]
+    requiredLabels: [custom_queue]
+    match:
+      name: kms_key_id
+      action: present
+  - code: CUS007
+    description: Custom instances must not be given a public IP
+    requiredTypes: [resource]
+    requiredLabels: [custom_instance]
+    match:
+      name: public_ip
+      action: absent
+  - code: CUS008
+    description: Custom images must be looked up by owner
+    requiredTypes: [data]
+    requiredLabels: [custom_image]
+    match:
+      name: owner
+      action: present
+`
+
+func Test_CustomChecks(t *testing.T) {
+
+	checks, err := custom.LoadCheckFile(createTestFile("custom.yaml", customChecks))
+	require.NoError(t, err)
+	require.NoError(t, custom.RegisterChecks(checks))
+
+	var tests = []struct {
+		name                  string
+		source                string
+		mustIncludeResultCode scanner.RuleID
+		mustExcludeResultCode scanner.RuleID
+	}{
+		{
+			name: "check tag is missing",
+			source: `
+resource "custom_bucket" "bucket" {
+	tags = {
+		Owner = "security"
+	}
+}`,
+			mustIncludeResultCode: "CUS001",
+		},
+		{
+			name: "check tag is present",
+			source: `
+resource "custom_bucket" "bucket" {
+	tags = {
+		CostCentre = "12345"
+	}
+}`,
+			mustExcludeResultCode: "CUS001",
+		},
+		{
+			name: "check tags are missing altogether",
+			source: `
+resource "custom_bucket" "bucket" {
+}`,
+			mustIncludeResultCode: "CUS001",
+		},
+		{
+			name: "check nested block condition fails",
+			source: `
+resource "custom_volume" "volume" {
+	retention_days = 30
+	encryption {
+		enabled = false
+	}
+}`,
+			mustIncludeResultCode: "CUS002",
+		},
+		{
+			name: "check numeric comparison fails",
+			source: `
+resource "custom_volume" "volume" {
+	retention_days = 3
+	encryption {
+		enabled = true
+	}
+}`,
+			mustIncludeResultCode: "CUS002",
+		},
+		{
+			name: "check all conditions pass",
+			source: `
+variable "retention" {
+	default = 14
+}
+
+resource "custom_volume" "volume" {
+	retention_days = var.retention
+	encryption {
+		enabled = true
+	}
+}`,
+			mustExcludeResultCode: "CUS002",
+		},
+		{
+			name: "check or and not conditions pass",
+			source: `
+resource "custom_database" "db" {
+	engine = "postgresql"
+}`,
+			mustExcludeResultCode: "CUS003",
+		},
+		{
+			name: "check not condition fails",
+			source: `
+resource "custom_database" "db" {
+	engine    = "mysql"
+	public_ip = "1.2.3.4"
+}`,
+			mustIncludeResultCode: "CUS003",
+		},
+		{
+			name: "check or condition fails",
+			source: `
+resource "custom_database" "db" {
+	engine = "oracle"
+}`,
+			mustIncludeResultCode: "CUS003",
+		},
+		{
+			name: "check expression is false",
+			source: `
+resource "custom_firewall" "fw" {
+	cidr_blocks = ["10.0.0.0/16", "0.0.0.0/0"]
+}`,
+			mustIncludeResultCode: "CUS004",
+		},
+		{
+			name: "check expression is true",
+			source: `
+locals {
+	allowed = ["10.0.0.0/16"]
+}
+
+resource "custom_firewall" "fw" {
+	cidr_blocks = local.allowed
+}`,
+			mustExcludeResultCode: "CUS004",
+		},
+		{
+			name: "check expression is unknown",
+			source: `
+resource "custom_firewall" "fw" {
+	cidr_blocks = [aws_vpc.main.cidr_block]
+}`,
+			mustExcludeResultCode: "CUS004",
+		},
+		{
+			name: "check expression with nested block is true",
+			source: `
+resource "custom_store" "store" {
+	encryption {
+		key_id = "my-key"
+	}
+}`,
+			mustExcludeResultCode: "CUS005",
+		},
+		{
+			name: "check expression with missing nested block is false",
+			source: `
+resource "custom_store" "store" {
+}`,
+			mustIncludeResultCode: "CUS005",
+		},
+		{
+			name: "check attribute referring to another resource is present",
+			source: `
+resource "aws_kms_key" "k" {
+}
+
+resource "custom_queue" "queue" {
+	kms_key_id = aws_kms_key.k.arn
+}`,
+			mustExcludeResultCode: "CUS006",
+		},
+		{
+			name: "check attribute set to null is not present",
+			source: `
+resource "custom_queue" "queue" {
+	kms_key_id = null
+}`,
+			mustIncludeResultCode: "CUS006",
+		},
+		{
+			name: "check attribute referring to another resource is not absent",
+			source: `
+resource "aws_eip" "ip" {
+}
+
+resource "custom_instance" "instance" {
+	public_ip = aws_eip.ip.public_ip
+}`,
+			mustIncludeResultCode: "CUS007",
+		},
+		{
+			name: "check attribute set to null is absent",
+			source: `
+resource "custom_instance" "instance" {
+	public_ip = null
+}`,
+			mustExcludeResultCode: "CUS007",
+		},
+		{
+			name: "check custom check can be ignored",
+			source: `
+resource "custom_bucket" "bucket" {
+	tags = {} #tfsec:ignore:CUS001
+}`,
+			mustExcludeResultCode: "CUS001",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanSource(test.source)
+			assertCheckCode(t, test.mustIncludeResultCode, test.mustExcludeResultCode, results)
+		})
+	}
+
+	t.Run("check nested block condition fails in JSON", func(t *testing.T) {
+		results := scanJSONSource(`{
+  "resource": {
+    "custom_volume": {
+      "volume": {
+        "retention_days": 30,
+        "encryption": {
+          "enabled": false
+        }
+      }
+    }
+  }
+}`)
+		assertCheckCode(t, "CUS002", "", results)
+	})
+
+	t.Run("check nested block condition passes in JSON", func(t *testing.T) {
+		results := scanJSONSource(`{
+  "resource": {
+    "custom_volume": {
+      "volume": {
+        "retention_days": 30,
+        "encryption": {
+          "enabled": true
+        }
+      }
+    }
+  }
+}`)
+		assertCheckCode(t, "", "CUS002", results)
+	})
+
+	t.Run("check results carry the custom message, severity and link", func(t *testing.T) {
+		results := scanSource(`
+resource "custom_bucket" "bucket" {
+	tags = {}
+}`)
+		require.Len(t, results, 1)
+		assert.Equal(t, "custom_bucket.bucket: The CostCentre tag is missing", results[0].Description)
+		assert.Equal(t, scanner.SeverityError, results[0].Severity)
+		assert.Equal(t, "https://example.com/standards/tagging", results[0].Link)
+		assert.Equal(t, 3, results[0].Range.StartLine)
+	})
+
+	t.Run("check results name the block they are about", func(t *testing.T) {
+		results := scanSource(`
+data "custom_image" "base" {
+	name = "ubuntu"
+}`)
+		require.Len(t, results, 1)
+		assert.Equal(t, "data.custom_image.base: Custom images must be looked up by owner", results[0].Description)
+	})
+
+	t.Run("check expression results point at the attribute referred to", func(t *testing.T) {
+		results := scanSource(`
+resource "custom_firewall" "fw" {
+	description = "public"
+	cidr_blocks = ["0.0.0.0/0"]
+}`)
+		require.Len(t, results, 1)
+		assert.Equal(t, scanner.RuleID("CUS004"), results[0].RuleID)
+		assert.Equal(t, 4, results[0].Range.StartLine)
+	})
+
+	t.Run("check codes must be unique", func(t *testing.T) {
+		assert.Error(t, custom.RegisterChecks(checks))
+	})
+}
+
+func Test_InvalidCustomChecks(t *testing.T) {
+
+	var tests = []struct {
+		name   string
+		source string
+	}{
+		{
+			name:   "missing code",
+			source: `{"checks": [{"description": "no code", "match": {"name": "a", "action": "present"}}]}`,
+		},
+		{
+			name:   "unknown action",
+			source: `{"checks": [{"code": "CUS100", "description": "unknown", "match": {"name": "a", "action": "sort-of"}}]}`,
+		},
+		{
+			name:   "invalid regex",
+			source: `{"checks": [{"code": "CUS101", "description": "regex", "match": {"name": "a", "action": "regex", "value": "("}}]}`,
+		},
+		{
+			name:   "non-numeric comparison",
+			source: `{"checks": [{"code": "CUS102", "description": "number", "match": {"name": "a", "action": "lessThan", "value": "ten"}}]}`,
+		},
+		{
+			name:   "not with several predicates",
+			source: `{"checks": [{"code": "CUS103", "description": "not", "match": {"action": "not", "predicates": [{"name": "a", "action": "present"}, {"name": "b", "action": "present"}]}}]}`,
+		},
+		{
+			name:   "invalid severity",
+			source: `{"checks": [{"code": "CUS104", "description": "severity", "severity": "BAD", "match": {"name": "a", "action": "present"}}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := custom.LoadCheckFile(createTestFile("checks.json", test.source))
+			assert.Error(t, err)
+		})
+	}
+}
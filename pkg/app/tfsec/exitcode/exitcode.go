@@ -0,0 +1,57 @@
+package exitcode
+
+import (
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+// exit codes, which tell pipelines whether any problems were found and how severe they were
+const (
+	NoProblems = 0
+	Errors     = 1
+	Warnings   = 2
+)
+
+// Options are the settings which change how the problems found affect the exit code
+type Options struct {
+	// SoftFail always exits with NoProblems
+	SoftFail bool
+	// SoftFailParseErrors stops files which could not be parsed from failing the scan
+	SoftFailParseErrors bool
+	// StrictIgnores fails the scan if any ignore comment didn't ignore anything
+	StrictIgnores bool
+}
+
+// Get returns Errors if any errors were found, including files which could not be parsed and, with StrictIgnores,
+// ignore comments which didn't ignore anything. It returns Warnings if only warnings or INFO problems were found; INFO
+// problems can be left out of the results with a minimum severity, so that they don't fail the scan.
+func Get(results []scanner.Result, diagnostics []parser.Diagnostic, ignoreWarnings []scanner.IgnoreWarning, options Options) int {
+	if options.SoftFail {
+		return NoProblems
+	}
+	if !options.SoftFailParseErrors && hasParseErrors(diagnostics) {
+		return Errors
+	}
+	if options.StrictIgnores && len(ignoreWarnings) > 0 {
+		return Errors
+	}
+	code := NoProblems
+	for _, result := range results {
+		switch result.Severity {
+		case scanner.SeverityError:
+			return Errors
+		default:
+			code = Warnings
+		}
+	}
+	return code
+}
+
+func hasParseErrors(diagnostics []parser.Diagnostic) bool {
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Type == parser.DiagnosticParseError {
+			return true
+		}
+	}
+	return false
+}
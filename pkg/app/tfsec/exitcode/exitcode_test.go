@@ -0,0 +1,91 @@
+package exitcode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_ExitCode(t *testing.T) {
+
+	errorResult := scanner.Result{RuleID: "AWS006", Severity: scanner.SeverityError}
+	warningResult := scanner.Result{RuleID: "AWS018", Severity: scanner.SeverityWarning}
+	infoResult := scanner.Result{RuleID: "GCP010", Severity: scanner.SeverityInfo}
+	parseError := parser.Diagnostic{Type: parser.DiagnosticParseError, Severity: "ERROR"}
+	evaluationError := parser.Diagnostic{Type: parser.DiagnosticEvaluationError, Severity: "ERROR"}
+	unusedIgnore := scanner.IgnoreWarning{Problem: scanner.IgnoreUnused}
+
+	var tests = []struct {
+		name           string
+		results        []scanner.Result
+		diagnostics    []parser.Diagnostic
+		ignoreWarnings []scanner.IgnoreWarning
+		options        Options
+		expected       int
+	}{
+		{
+			name:     "no problems",
+			expected: NoProblems,
+		},
+		{
+			name:     "errors and warnings",
+			results:  []scanner.Result{warningResult, errorResult, infoResult},
+			expected: Errors,
+		},
+		{
+			name:     "warnings and info",
+			results:  []scanner.Result{infoResult, warningResult},
+			expected: Warnings,
+		},
+		{
+			name:     "only info",
+			results:  []scanner.Result{infoResult, infoResult},
+			expected: Warnings,
+		},
+		{
+			name:     "soft fail",
+			results:  []scanner.Result{errorResult},
+			options:  Options{SoftFail: true},
+			expected: NoProblems,
+		},
+		{
+			name:        "parse error",
+			results:     []scanner.Result{warningResult},
+			diagnostics: []parser.Diagnostic{parseError},
+			expected:    Errors,
+		},
+		{
+			name:        "parse error with soft fail parse errors",
+			results:     []scanner.Result{warningResult},
+			diagnostics: []parser.Diagnostic{parseError},
+			options:     Options{SoftFailParseErrors: true},
+			expected:    Warnings,
+		},
+		{
+			name:        "evaluation error",
+			diagnostics: []parser.Diagnostic{evaluationError},
+			expected:    NoProblems,
+		},
+		{
+			name:           "unused ignore",
+			ignoreWarnings: []scanner.IgnoreWarning{unusedIgnore},
+			expected:       NoProblems,
+		},
+		{
+			name:           "unused ignore with strict ignores",
+			results:        []scanner.Result{infoResult},
+			ignoreWarnings: []scanner.IgnoreWarning{unusedIgnore},
+			options:        Options{StrictIgnores: true},
+			expected:       Errors,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Get(test.results, test.diagnostics, test.ignoreWarnings, test.options))
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package tfsec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_ProblemInExpandedResource(t *testing.T) {
+
+	var tests = []struct {
+		name                  string
+		source                string
+		mustIncludeResultCode scanner.RuleID
+		mustExcludeResultCode scanner.RuleID
+	}{
+		{
+			name: "check problem using count.index",
+			source: `
+variable "acls" {
+	default = ["private", "public-read"]
+}
+
+resource "aws_s3_bucket" "my-bucket" {
+	count = 2
+	acl   = var.acls[count.index]
+}`,
+			mustIncludeResultCode: checks.AWSBadBucketACL,
+		},
+		{
+			name: "check problem using each.value",
+			source: `
+resource "aws_s3_bucket" "my-bucket" {
+	for_each = {
+		logs = "public-read"
+	}
+	acl = each.value
+}`,
+			mustIncludeResultCode: checks.AWSBadBucketACL,
+		},
+		{
+			name: "check no problem when count is zero",
+			source: `
+resource "aws_s3_bucket" "my-bucket" {
+	count = 0
+	acl   = "public-read"
+}`,
+			mustExcludeResultCode: checks.AWSBadBucketACL,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanSource(test.source)
+			assertCheckCode(t, test.mustIncludeResultCode, test.mustExcludeResultCode, results)
+		})
+	}
+}
+
+func Test_ExpandedResourceResultsReportInstanceAddress(t *testing.T) {
+
+	results := scanSource(`
+resource "aws_s3_bucket" "my-bucket" {
+	for_each = {
+		dev  = "private"
+		prod = "public-read"
+	}
+	acl = each.value
+}`)
+
+	var bucketResults []scanner.Result
+	for _, result := range results {
+		if result.RuleID == checks.AWSBadBucketACL {
+			bucketResults = append(bucketResults, result)
+		}
+	}
+
+	require.Len(t, bucketResults, 1)
+	assert.Contains(t, bucketResults[0].Description, `aws_s3_bucket.my-bucket["prod"]`)
+}
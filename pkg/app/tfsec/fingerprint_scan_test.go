@@ -0,0 +1,110 @@
+package tfsec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_FingerprintsSurviveLineShiftsAndFileMoves(t *testing.T) {
+
+	task := `
+resource "aws_ecs_task_definition" "task" {
+	container_definitions = <<DEFINITIONS
+[{"environment": [
+	{"name": "DB_PASSWORD", "value": "hunter2"},
+	{"name": "API_TOKEN", "value": "abc123"}
+]}]
+DEFINITIONS
+}
+`
+
+	original := scanSource(task)
+	shifted := scanDirectory(t, map[string]string{
+		"main.tf":    `# the task has moved`,
+		"network.tf": "# some lines\n# pushing the task\n# further down\n" + task,
+	})
+
+	require.Len(t, original, 2)
+	require.Len(t, shifted, 2)
+	assert.Equal(t, checks.AWSTaskDefinitionWithSensitiveEnvironmentVariables, original[0].RuleID)
+	assert.NotEqual(t, original[0].Fingerprint, original[1].Fingerprint)
+	assert.Equal(t, original[0].Fingerprint, shifted[0].Fingerprint)
+	assert.Equal(t, original[1].Fingerprint, shifted[1].Fingerprint)
+
+	renamed := scanSource(strings.Replace(task, `"task"`, `"renamed"`, 1))
+	require.Len(t, renamed, 2)
+	assert.NotEqual(t, original[0].Fingerprint, renamed[0].Fingerprint)
+}
+
+func scanDirectory(t *testing.T, files map[string]string) []scanner.Result {
+	var dir string
+	for filename, contents := range files {
+		if dir == "" {
+			dir = filepath.Dir(createTestFile(filename, contents))
+			continue
+		}
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, filename), []byte(contents), 0600))
+	}
+	blocks, err := parser.New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+	return scanner.New().Scan(blocks, excludedChecksList)
+}
+
+func Test_ResultsDescribeTheirResource(t *testing.T) {
+
+	results := scanSource(`
+resource "aws_security_group_rule" "ingress" {
+	provider    = aws.west
+	type        = "ingress"
+	description = "open to the world"
+	cidr_blocks = ["0.0.0.0/0"]
+}
+`)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, "aws_security_group_rule.ingress", results[0].Address)
+	assert.Equal(t, "resource", results[0].BlockType)
+	assert.Equal(t, "aws_security_group_rule", results[0].ResourceType)
+	assert.Equal(t, "", results[0].ModulePath)
+	assert.Equal(t, "aws.west", results[0].Provider)
+	assert.Equal(t, "cidr_blocks", results[0].AttributePath)
+	assert.NotEmpty(t, results[0].Fingerprint)
+
+	path := createTestFileWithModule(`
+module "public" {
+	source = "../module"
+	acl    = "public-read"
+}
+`, `
+variable "acl" {}
+
+resource "aws_s3_bucket" "bucket" {
+	acl = var.acl
+}
+`)
+
+	blocks, err := parser.New().ParseDirectory(path, nil)
+	require.NoError(t, err)
+
+	var aclResults []scanner.Result
+	for _, result := range scanner.New().Scan(blocks, excludedChecksList) {
+		if result.RuleID == checks.AWSBadBucketACL {
+			aclResults = append(aclResults, result)
+		}
+	}
+
+	require.Len(t, aclResults, 1)
+	assert.Equal(t, "module.public.aws_s3_bucket.bucket", aclResults[0].Address)
+	assert.Equal(t, "module.public", aclResults[0].ModulePath)
+	assert.Equal(t, "aws", aclResults[0].Provider)
+	assert.Equal(t, "acl", aclResults[0].AttributePath)
+}
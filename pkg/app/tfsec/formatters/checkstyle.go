@@ -3,7 +3,9 @@ package formatters
 import (
 	"encoding/xml"
 	"io"
+	"sort"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
@@ -14,6 +16,16 @@ type checkstyleResult struct {
 	Severity string `xml:"severity,attr"`
 	Message  string `xml:"message,attr"`
 	Link     string `xml:"link,attr"`
+	// the remaining attributes describe which block and attribute the problem is about
+	Address       string `xml:"address,attr,omitempty"`
+	BlockType     string `xml:"block_type,attr,omitempty"`
+	ResourceType  string `xml:"resource_type,attr,omitempty"`
+	ModulePath    string `xml:"module_path,attr,omitempty"`
+	Provider      string `xml:"provider,attr,omitempty"`
+	AttributePath string `xml:"attribute_path,attr,omitempty"`
+	Fingerprint   string `xml:"fingerprint,attr,omitempty"`
+	IgnoreReason  string `xml:"ignore_reason,attr,omitempty"`
+	IgnoreExpiry  string `xml:"ignore_expiry,attr,omitempty"`
 }
 
 type checkstyleFile struct {
@@ -26,32 +38,64 @@ type checkstyleOutput struct {
 	Files   []checkstyleFile `xml:"file"`
 }
 
-func FormatCheckStyle(w io.Writer, results []scanner.Result) error {
+func FormatCheckStyle(w io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, _ *parser.Sources) error {
 
 	output := checkstyleOutput{}
 
 	files := make(map[string][]checkstyleResult)
 
 	for _, result := range results {
-		fileResults := append(
-			files[result.Range.Filename],
+		entry := checkstyleResult{
+			Rule:     string(result.RuleID),
+			Line:     result.Range.StartLine,
+			Column:   result.Range.StartColumn,
+			Severity: string(result.Severity),
+			Message:  result.Description,
+			Link:     result.Link,
+
+			Address:       result.Address,
+			BlockType:     result.BlockType,
+			ResourceType:  result.ResourceType,
+			ModulePath:    result.ModulePath,
+			Provider:      result.Provider,
+			AttributePath: result.AttributePath,
+			Fingerprint:   result.Fingerprint,
+		}
+		// checkstyle's own severity for problems which have been suppressed
+		if result.Ignored != nil {
+			entry.Severity = "ignore"
+			entry.IgnoreReason = result.Ignored.Reason
+			entry.IgnoreExpiry = result.Ignored.Expiry
+		}
+		files[result.Range.Filename] = append(files[result.Range.Filename], entry)
+	}
+
+	for _, diagnostic := range diagnostics {
+		files[diagnostic.Range.Filename] = append(
+			files[diagnostic.Range.Filename],
 			checkstyleResult{
-				Rule:     string(result.RuleID),
-				Line:     result.Range.StartLine,
-				Severity: string(result.Severity),
-				Message:  result.Description,
-				Link:     result.Link,
+				Rule:     string(diagnostic.Type),
+				Line:     diagnostic.Range.StartLine,
+				Column:   diagnostic.Range.StartColumn,
+				Severity: diagnostic.Severity,
+				Message:  diagnosticMessage(diagnostic),
 			},
 		)
-		files[result.Range.Filename] = fileResults
 	}
 
-	for name, fileResults := range files {
+	// files are written in order of name, so the output is the same on every run
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
 		output.Files = append(
 			output.Files,
 			checkstyleFile{
 				Name:   name,
-				Errors: fileResults,
+				Errors: files[name],
 			},
 		)
 	}
@@ -0,0 +1,36 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/xml"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_CheckStyleWritesFilesInOrder(t *testing.T) {
+
+	var results []scanner.Result
+	for _, filename := range []string{"c.tf", "a.tf", "e.tf", "b.tf", "d.tf"} {
+		results = append(results, scanner.Result{
+			RuleID:   "AWS006",
+			Severity: scanner.SeverityWarning,
+			Range:    parser.Range{Filename: filename, StartLine: 1, EndLine: 1},
+		})
+	}
+
+	var output bytes.Buffer
+	require.NoError(t, FormatCheckStyle(&output, results, nil, nil))
+
+	var decoded checkstyleOutput
+	require.NoError(t, xml.Unmarshal(output.Bytes(), &decoded))
+	var names []string
+	for _, file := range decoded.Files {
+		names = append(names, file.Name)
+	}
+	assert.Equal(t, []string{"a.tf", "b.tf", "c.tf", "d.tf", "e.tf"}, names)
+}
@@ -6,16 +6,23 @@ import (
 	"io"
 	"strconv"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
-func FormatCSV(w io.Writer, results []scanner.Result) error {
+func FormatCSV(w io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, _ *parser.Sources) error {
 
 	records := [][]string{
-		{"file", "start_line", "end_line", "rule_id", "severity", "description", "link"},
+		{"file", "start_line", "end_line", "rule_id", "severity", "description", "link", "start_column", "end_column", "address", "block_type", "resource_type", "module_path", "provider", "attribute_path", "fingerprint", "ignored", "ignore_reason", "ignore_expiry"},
 	}
 
 	for _, result := range results {
+		var ignored, ignoreReason, ignoreExpiry string
+		if result.Ignored != nil {
+			ignored = "true"
+			ignoreReason = result.Ignored.Reason
+			ignoreExpiry = result.Ignored.Expiry
+		}
 		records = append(records, []string{
 			result.Range.Filename,
 			strconv.Itoa(result.Range.StartLine),
@@ -24,6 +31,34 @@ func FormatCSV(w io.Writer, results []scanner.Result) error {
 			string(result.Severity),
 			result.Description,
 			result.Link,
+			strconv.Itoa(result.Range.StartColumn),
+			strconv.Itoa(result.Range.EndColumn),
+			result.Address,
+			result.BlockType,
+			result.ResourceType,
+			result.ModulePath,
+			result.Provider,
+			result.AttributePath,
+			result.Fingerprint,
+			ignored,
+			ignoreReason,
+			ignoreExpiry,
+		})
+	}
+
+	// diagnostics have no rule, so are identified by their type instead
+	for _, diagnostic := range diagnostics {
+		records = append(records, []string{
+			diagnostic.Range.Filename,
+			strconv.Itoa(diagnostic.Range.StartLine),
+			strconv.Itoa(diagnostic.Range.EndLine),
+			string(diagnostic.Type),
+			diagnostic.Severity,
+			diagnosticMessage(diagnostic),
+			"",
+			strconv.Itoa(diagnostic.Range.StartColumn),
+			strconv.Itoa(diagnostic.Range.EndColumn),
+			"", "", "", "", "", "", "", "", "", "",
 		})
 	}
 
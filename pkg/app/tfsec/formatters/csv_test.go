@@ -0,0 +1,47 @@
+package formatters
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_CSVKeepsOriginalColumnsInPlace(t *testing.T) {
+
+	results := []scanner.Result{{
+		RuleID:      "AWS006",
+		Link:        "https://example.com/AWS006",
+		Description: "open ingress",
+		Severity:    scanner.SeverityWarning,
+		Range:       parser.Range{Filename: "main.tf", StartLine: 3, EndLine: 4, StartColumn: 5, EndColumn: 18},
+		Address:     "aws_security_group_rule.rule",
+		Fingerprint: "abcd",
+	}}
+	diagnostics := []parser.Diagnostic{{
+		Type:     parser.DiagnosticParseError,
+		Severity: "ERROR",
+		Summary:  "Invalid block",
+		Range:    parser.Range{Filename: "broken.tf", StartLine: 1, EndLine: 1, StartColumn: 2, EndColumn: 9},
+	}}
+
+	var output bytes.Buffer
+	require.NoError(t, FormatCSV(&output, results, diagnostics, nil))
+	records, err := csv.NewReader(&output).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	// columns are only ever added at the end, so consumers reading columns by position keep working
+	assert.Equal(t, []string{"file", "start_line", "end_line", "rule_id", "severity", "description", "link"}, records[0][:7])
+	assert.Equal(t, []string{"start_column", "end_column", "address"}, records[0][7:10])
+	assert.Equal(t, []string{"main.tf", "3", "4", "AWS006", "WARNING", "open ingress", "https://example.com/AWS006", "5", "18", "aws_security_group_rule.rule"}, records[1][:10])
+	assert.Equal(t, []string{"broken.tf", "1", "1", "parse_error", "ERROR", "Invalid block", "", "2", "9", ""}, records[2][:10])
+	for _, record := range records {
+		assert.Len(t, record, len(records[0]))
+	}
+}
@@ -3,25 +3,45 @@ package formatters
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
-	"strings"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 	"github.com/liamg/clinch/terminal"
 	"github.com/liamg/tml"
 )
 
-func FormatDefault(_ io.Writer, results []scanner.Result) error {
+func FormatDefault(_ io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, sources *parser.Sources) error {
 
-	if len(results) == 0 {
+	if len(diagnostics) > 0 {
+		terminal.PrintErrorf("\n%d problems prevented a full scan:\n\n", len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			_ = tml.Printf("  <yellow>%s</yellow>\n  <blue>%s</blue>\n\n", diagnosticMessage(diagnostic), diagnostic.Range.String())
+		}
+	}
+
+	problems := countProblems(results)
+	if problems == 0 {
 		terminal.PrintSuccessf("\nNo problems detected!\n")
 	}
 
 	var severity string
 
-	terminal.PrintErrorf("\n%d potential problems detected:\n\n", len(results))
+	terminal.PrintErrorf("\n%d potential problems detected:\n\n", problems)
+	snippets := newSnippets(results, sources)
+	results, multipleRoots := groupByRootModule(results)
+	var rootModule string
+	var number int
 	for i, result := range results {
-		terminal.PrintErrorf("<underline>Problem %d</underline>\n", i+1)
+		if multipleRoots && (i == 0 || result.RootModule != rootModule) {
+			rootModule = result.RootModule
+			_ = tml.Printf("<bold>Root module %s</bold>\n\n", rootModule)
+		}
+		if result.Ignored != nil {
+			_ = tml.Printf("<underline>Ignored problem</underline>\n")
+		} else {
+			number++
+			terminal.PrintErrorf("<underline>Problem %d</underline>\n", number)
+		}
 
 		switch result.Severity {
 		case scanner.SeverityError:
@@ -37,7 +57,13 @@ func FormatDefault(_ io.Writer, results []scanner.Result) error {
   <blue>%s</blue>
 
 `, result.RuleID, severity, result.Description, result.Range.String())
-		highlightCode(result)
+		if metadata := resultMetadata(result); len(metadata) > 0 {
+			for _, field := range metadata {
+				_ = tml.Printf("  <blue>%s:</blue> %s\n", field.name, field.value)
+			}
+			fmt.Println("")
+		}
+		highlightCode(result, snippets)
 		tml.Printf("  <blue>See %s for more information.</blue>\n\n", result.Link)
 	}
 
@@ -46,15 +72,13 @@ func FormatDefault(_ io.Writer, results []scanner.Result) error {
 }
 
 // highlight the lines of code which caused a problem, if available
-func highlightCode(result scanner.Result) {
+func highlightCode(result scanner.Result, snippets *snippets) {
 
-	data, err := ioutil.ReadFile(result.Range.Filename)
-	if err != nil {
+	lines, ok := snippets.lines(result)
+	if !ok {
 		return
 	}
 
-	lines := append([]string{""}, strings.Split(string(data), "\n")...)
-
 	start := result.Range.StartLine - 3
 	if start <= 0 {
 		start = 1
@@ -68,7 +92,7 @@ func highlightCode(result scanner.Result) {
 		_ = tml.Printf("  <blue>% 6d</blue> | ", lineNo)
 		if lineNo >= result.Range.StartLine && lineNo <= result.Range.EndLine {
 			if lineNo == result.Range.StartLine && result.RangeAnnotation != "" {
-				_ = tml.Printf("<bold><red>%s</red>    <blue>%s</blue></bold>\n", lines[lineNo], result.RangeAnnotation)
+				_ = tml.Printf("<bold><red>%s</red>    <blue>%s</blue></bold>\n", lines[lineNo], snippets.annotation(result))
 			} else {
 				_ = tml.Printf("<bold><red>%s</red></bold>\n", lines[lineNo])
 			}
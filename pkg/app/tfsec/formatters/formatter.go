@@ -1,10 +1,172 @@
 package formatters
 
 import (
+	"fmt"
 	"io"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
-// Formatter formats scan results into a specific format
-type Formatter func(w io.Writer, results []scanner.Result) error
+// Formatter formats scan results into a specific format, along with any diagnostics describing parts of the
+// configuration which could not be scanned. Code snippets are taken from the sources the configuration was parsed from.
+type Formatter func(w io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, sources *parser.Sources) error
+
+// groupByRootModule orders results by root module, keeping their original order within each root module. It also
+// reports whether the results span more than one root module, in which case a heading should be shown for each.
+func groupByRootModule(results []scanner.Result) ([]scanner.Result, bool) {
+	grouped := make([]scanner.Result, len(results))
+	copy(grouped, results)
+	sort.SliceStable(grouped, func(i, j int) bool {
+		return grouped[i].RootModule < grouped[j].RootModule
+	})
+	multiple := len(grouped) > 0 && grouped[0].RootModule != grouped[len(grouped)-1].RootModule
+	return grouped, multiple
+}
+
+type metadataField struct {
+	name  string
+	value string
+}
+
+// resultMetadata returns the fields describing which block and attribute a result is about, leaving out any which
+// don't apply
+func resultMetadata(result scanner.Result) []metadataField {
+	var fields []metadataField
+	for _, field := range []metadataField{
+		{"Ignored", ignoreDescription(result)},
+		{"Address", result.Address},
+		{"Block type", result.BlockType},
+		{"Resource type", result.ResourceType},
+		{"Module", result.ModulePath},
+		{"Provider", result.Provider},
+		{"Attribute", result.AttributePath},
+		{"Fingerprint", result.Fingerprint},
+	} {
+		if field.value != "" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// countProblems returns the number of results which haven't been ignored
+func countProblems(results []scanner.Result) int {
+	var count int
+	for _, result := range results {
+		if result.Ignored == nil {
+			count++
+		}
+	}
+	return count
+}
+
+// ignoreDescription describes the comment which ignored a result, or returns an empty string if it wasn't ignored
+func ignoreDescription(result scanner.Result) string {
+	if result.Ignored == nil {
+		return ""
+	}
+	description := result.Ignored.Reason
+	if description == "" {
+		description = "no reason given"
+	}
+	if result.Ignored.Expiry != "" {
+		description += fmt.Sprintf(" (until %s)", result.Ignored.Expiry)
+	}
+	return description + fmt.Sprintf(" at %s", result.Ignored.Range.String())
+}
+
+func diagnosticMessage(diagnostic parser.Diagnostic) string {
+	if diagnostic.Detail == "" {
+		return diagnostic.Summary
+	}
+	return diagnostic.Summary + "; " + diagnostic.Detail
+}
+
+// snippets looks up the lines of code shown with each result. Every secret in the scan is masked in every snippet and
+// annotation, as the lines shown with one result may reveal a secret which only another result knows about.
+type snippets struct {
+	sources *parser.Sources
+	secrets []string
+	masked  map[string][]string
+}
+
+// newSnippets masks the secrets revealed by any of the results, as well as the values of sensitive variables, locals
+// and outputs recorded in the sources
+func newSnippets(results []scanner.Result, sources *parser.Sources) *snippets {
+	unique := make(map[string]bool)
+	for _, secret := range sources.Secrets() {
+		unique[secret] = true
+	}
+	for _, result := range results {
+		for _, secret := range result.Secrets {
+			if secret != "" {
+				unique[secret] = true
+			}
+		}
+	}
+	var secrets []string
+	for secret := range unique {
+		secrets = append(secrets, secret)
+	}
+	// longer secrets are masked first, so a secret containing another is masked as a whole
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+	return &snippets{
+		sources: sources,
+		secrets: secrets,
+		masked:  make(map[string][]string),
+	}
+}
+
+// lines returns the lines of the file a result is in with any secrets masked, with the first line at index 1
+func (snippets *snippets) lines(result scanner.Result) ([]string, bool) {
+	filename := result.Range.Filename
+	if lines, ok := snippets.masked[filename]; ok {
+		return lines, true
+	}
+	contents, ok := snippets.sources.Contents(filename)
+	if !ok {
+		return nil, false
+	}
+	lines := append([]string{""}, strings.Split(redactSecrets(contents, snippets.secrets), "\n")...)
+	snippets.masked[filename] = lines
+	return lines, true
+}
+
+// annotation returns the value annotation of a result with any secrets masked
+func (snippets *snippets) annotation(result scanner.Result) string {
+	return redactSecrets(result.RangeAnnotation, snippets.secrets)
+}
+
+const redactedSecret = "********"
+
+// redactSecrets masks each of the given secrets in the text, whether it appears as-is or escaped within a quoted
+// string. Secrets spanning several lines are masked line by line, so line numbers in the text are unchanged.
+func redactSecrets(text string, secrets []string) string {
+	for _, secret := range secrets {
+		if secret == "" {
+			continue
+		}
+		text = strings.ReplaceAll(text, secret, maskLines(secret))
+		if quoted := strconv.Quote(secret); quoted[1:len(quoted)-1] != secret {
+			text = strings.ReplaceAll(text, quoted[1:len(quoted)-1], redactedSecret)
+		}
+	}
+	return text
+}
+
+func maskLines(secret string) string {
+	lines := strings.Split(secret, "\n")
+	for i := range lines {
+		lines[i] = redactedSecret
+	}
+	return strings.Join(lines, "\n")
+}
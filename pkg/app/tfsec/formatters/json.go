@@ -4,16 +4,18 @@ import (
 	"encoding/json"
 	"io"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
 type JSONOutput struct {
-	Results []scanner.Result `json:"results"`
+	Results     []scanner.Result    `json:"results"`
+	Diagnostics []parser.Diagnostic `json:"diagnostics,omitempty"`
 }
 
-func FormatJSON(w io.Writer, results []scanner.Result) error {
+func FormatJSON(w io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, _ *parser.Sources) error {
 	jsonWriter := json.NewEncoder(w)
 	jsonWriter.SetIndent("", "\t")
 
-	return jsonWriter.Encode(JSONOutput{results})
+	return jsonWriter.Encode(JSONOutput{results, diagnostics})
 }
@@ -4,9 +4,8 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"strings"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
@@ -25,11 +24,29 @@ type JUnitTestSuite struct {
 
 // JUnitTestCase is a single test case with its result.
 type JUnitTestCase struct {
-	XMLName   xml.Name      `xml:"testcase"`
-	Classname string        `xml:"classname,attr"`
-	Name      string        `xml:"name,attr"`
-	Time      string        `xml:"time,attr"`
-	Failure   *JUnitFailure `xml:"failure,omitempty"`
+	XMLName    xml.Name         `xml:"testcase"`
+	Classname  string           `xml:"classname,attr"`
+	Name       string           `xml:"name,attr"`
+	Time       string           `xml:"time,attr"`
+	Properties *JUnitProperties `xml:"properties,omitempty"`
+	Skipped    *JUnitSkipped    `xml:"skipped,omitempty"`
+	Failure    *JUnitFailure    `xml:"failure,omitempty"`
+}
+
+// JUnitSkipped marks a test case whose problem has been ignored with a comment, giving the reason.
+type JUnitSkipped struct {
+	Message string `xml:"message,attr"`
+}
+
+// JUnitProperties holds the properties of a test case, which describe the block and attribute a problem is about.
+type JUnitProperties struct {
+	Properties []JUnitProperty `xml:"property"`
+}
+
+// JUnitProperty is a single named property of a test case.
+type JUnitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 // JUnitFailure contains data related to a failed test.
@@ -39,25 +56,53 @@ type JUnitFailure struct {
 	Contents string `xml:",chardata"`
 }
 
-func FormatJUnit(w io.Writer, results []scanner.Result) error {
+func FormatJUnit(w io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, sources *parser.Sources) error {
 
 	output := JUnitTestSuite{
 		Name:     "tfsec",
-		Failures: fmt.Sprintf("%d", len(results)),
-		Tests:    fmt.Sprintf("%d", len(results)),
+		Failures: fmt.Sprintf("%d", countProblems(results)+len(diagnostics)),
+		Tests:    fmt.Sprintf("%d", len(results)+len(diagnostics)),
 	}
 
-	for _, result := range results {
+	for _, diagnostic := range diagnostics {
 		output.TestCases = append(output.TestCases,
 			JUnitTestCase{
-				Classname: result.Range.Filename,
-				Name:      fmt.Sprintf("[%s][%s]", result.RuleID, result.Severity),
+				Classname: diagnostic.Range.Filename,
+				Name:      fmt.Sprintf("[%s][%s]", diagnostic.Type, diagnostic.Severity),
 				Time:      "0",
+				Failure: &JUnitFailure{
+					Message:  diagnosticMessage(diagnostic),
+					Contents: diagnostic.Range.String(),
+				},
+			},
+		)
+	}
+
+	snippets := newSnippets(results, sources)
+	for _, result := range results {
+		if result.Ignored != nil {
+			output.TestCases = append(output.TestCases,
+				JUnitTestCase{
+					Classname:  result.Range.Filename,
+					Name:       fmt.Sprintf("[%s][%s]", result.RuleID, result.Severity),
+					Time:       "0",
+					Properties: junitProperties(result),
+					Skipped:    &JUnitSkipped{Message: ignoreDescription(result)},
+				},
+			)
+			continue
+		}
+		output.TestCases = append(output.TestCases,
+			JUnitTestCase{
+				Classname:  result.Range.Filename,
+				Name:       fmt.Sprintf("[%s][%s]", result.RuleID, result.Severity),
+				Time:       "0",
+				Properties: junitProperties(result),
 				Failure: &JUnitFailure{
 					Message: result.Description,
 					Contents: fmt.Sprintf("%s\n%s\nMore information: %s",
 						result.Range.String(),
-						highlightCodeJunit(result),
+						highlightCodeJunit(result, snippets),
 						result.Link),
 				},
 			},
@@ -74,16 +119,28 @@ func FormatJUnit(w io.Writer, results []scanner.Result) error {
 	return xmlEncoder.Encode(output)
 }
 
+func junitProperties(result scanner.Result) *JUnitProperties {
+	return &JUnitProperties{
+		Properties: []JUnitProperty{
+			{Name: "address", Value: result.Address},
+			{Name: "block_type", Value: result.BlockType},
+			{Name: "resource_type", Value: result.ResourceType},
+			{Name: "module_path", Value: result.ModulePath},
+			{Name: "provider", Value: result.Provider},
+			{Name: "attribute_path", Value: result.AttributePath},
+			{Name: "fingerprint", Value: result.Fingerprint},
+		},
+	}
+}
+
 // highlight the lines of code which caused a problem, if available
-func highlightCodeJunit(result scanner.Result) string {
+func highlightCodeJunit(result scanner.Result, snippets *snippets) string {
 
-	data, err := ioutil.ReadFile(result.Range.Filename)
-	if err != nil {
+	lines, ok := snippets.lines(result)
+	if !ok {
 		return ""
 	}
 
-	lines := append([]string{""}, strings.Split(string(data), "\n")...)
-
 	start := result.Range.StartLine - 3
 	if start <= 0 {
 		start = 1
@@ -99,7 +156,7 @@ func highlightCodeJunit(result scanner.Result) string {
 		output += fmt.Sprintf("  % 6d | ", lineNo)
 		if lineNo >= result.Range.StartLine && lineNo <= result.Range.EndLine {
 			if lineNo == result.Range.StartLine && result.RangeAnnotation != "" {
-				output += fmt.Sprintf("%s    %s\n", lines[lineNo], result.RangeAnnotation)
+				output += fmt.Sprintf("%s    %s\n", lines[lineNo], snippets.annotation(result))
 			} else {
 				output += fmt.Sprintf("%s\n", lines[lineNo])
 			}
@@ -3,23 +3,43 @@ package formatters
 import (
 	"fmt"
 	"io"
-	"io/ioutil"
-	"strings"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
 
-func FormatText(_ io.Writer, results []scanner.Result) error {
+func FormatText(_ io.Writer, results []scanner.Result, diagnostics []parser.Diagnostic, sources *parser.Sources) error {
 
-	if len(results) == 0 {
+	if len(diagnostics) > 0 {
+		fmt.Printf("\n%d problems prevented a full scan:\n\n", len(diagnostics))
+		for _, diagnostic := range diagnostics {
+			fmt.Printf("  %s\n  %s\n\n", diagnosticMessage(diagnostic), diagnostic.Range.String())
+		}
+	}
+
+	problems := countProblems(results)
+	if problems == 0 {
 		fmt.Print("\nNo problems detected!\n")
 	}
 
 	var severity string
 
-	fmt.Printf("\n%d potential problems detected:\n\n", len(results))
+	fmt.Printf("\n%d potential problems detected:\n\n", problems)
+	snippets := newSnippets(results, sources)
+	results, multipleRoots := groupByRootModule(results)
+	var rootModule string
+	var number int
 	for i, result := range results {
-		fmt.Printf("Problem %d\n", i+1)
+		if multipleRoots && (i == 0 || result.RootModule != rootModule) {
+			rootModule = result.RootModule
+			fmt.Printf("Root module %s\n\n", rootModule)
+		}
+		if result.Ignored != nil {
+			fmt.Print("Ignored problem\n")
+		} else {
+			number++
+			fmt.Printf("Problem %d\n", number)
+		}
 
 		switch result.Severity {
 		case scanner.SeverityError:
@@ -35,7 +55,13 @@ func FormatText(_ io.Writer, results []scanner.Result) error {
   %s
 
 `, result.RuleID, severity, result.Description, result.Range.String())
-		outputCode(result)
+		if metadata := resultMetadata(result); len(metadata) > 0 {
+			for _, field := range metadata {
+				fmt.Printf("  %s: %s\n", field.name, field.value)
+			}
+			fmt.Println("")
+		}
+		outputCode(result, snippets)
 		fmt.Printf("  See %s for more information.\n\n", result.Link)
 	}
 
@@ -44,14 +70,12 @@ func FormatText(_ io.Writer, results []scanner.Result) error {
 }
 
 // output the lines of code which caused a problem, if available
-func outputCode(result scanner.Result) {
-	data, err := ioutil.ReadFile(result.Range.Filename)
-	if err != nil {
+func outputCode(result scanner.Result, snippets *snippets) {
+	lines, ok := snippets.lines(result)
+	if !ok {
 		return
 	}
 
-	lines := append([]string{""}, strings.Split(string(data), "\n")...)
-
 	start := result.Range.StartLine - 3
 	if start <= 0 {
 		start = 1
@@ -65,7 +89,7 @@ func outputCode(result scanner.Result) {
 		fmt.Printf("  % 6d | ", lineNo)
 		if lineNo >= result.Range.StartLine && lineNo <= result.Range.EndLine {
 			if lineNo == result.Range.StartLine && result.RangeAnnotation != "" {
-				fmt.Printf("%s    %s\n", lines[lineNo], result.RangeAnnotation)
+				fmt.Printf("%s    %s\n", lines[lineNo], snippets.annotation(result))
 			} else {
 				fmt.Printf("%s\n", lines[lineNo])
 			}
@@ -1,10 +1,12 @@
 package tfsec
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 
@@ -52,3 +54,275 @@ resource "bad" "my-bad" {} //tfsec:ignore:ABC123
 	assert.Equal(t, results[0].RuleID, scanner.RuleID("DEF456"))
 
 }
+
+func Test_IgnoreInSourceWhichIsNotOnDisk(t *testing.T) {
+
+	tfParser := parser.New()
+	blocks, err := tfParser.ParseSource("/generated/main.tf", []byte(`
+resource "aws_security_group_rule" "ignored" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"] // tfsec:ignore:AWS006
+}
+
+resource "aws_security_group_rule" "reported" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`))
+	require.NoError(t, err)
+
+	var addresses []string
+	for _, result := range scanner.New(scanner.OptionWithSources(tfParser.Sources())).Scan(blocks, excludedChecksList) {
+		if result.RuleID == checks.AWSOpenIngressSecurityGroupRule {
+			addresses = append(addresses, result.Address)
+		}
+	}
+	assert.Equal(t, []string{"aws_security_group_rule.reported"}, addresses)
+}
+
+func Test_IgnoreWithExpiry(t *testing.T) {
+
+	var tests = []struct {
+		name          string
+		comment       string
+		expectIgnored bool
+	}{
+		{name: "expiry in the future", comment: "tfsec:ignore:AWS006:exp:2999-12-31", expectIgnored: true},
+		{name: "expiry in the past", comment: "tfsec:ignore:AWS006:exp:2001-01-01", expectIgnored: false},
+		{name: "unreadable expiry", comment: "tfsec:ignore:AWS006:exp:31/12/2999", expectIgnored: false},
+		{name: "expiry and reason", comment: `tfsec:ignore:AWS006:exp:2999-12-31 reason="vendor VPN"`, expectIgnored: true},
+		{name: "expiry of another check", comment: "tfsec:ignore:AWS007:exp:2999-12-31", expectIgnored: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanSource(fmt.Sprintf(`
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"] # %s
+}
+`, test.comment))
+			if test.expectIgnored {
+				assertCheckCode(t, "", checks.AWSOpenIngressSecurityGroupRule, results)
+			} else {
+				assertCheckCode(t, checks.AWSOpenIngressSecurityGroupRule, "", results)
+			}
+		})
+	}
+}
+
+func Test_IgnoredResultsRecordTheirReason(t *testing.T) {
+
+	blocks := createBlocksFromSource(`
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    # tfsec:ignore:AWS006:exp:2999-12-31 reason="vendor VPN"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`)
+
+	assertCheckCode(t, "", checks.AWSOpenIngressSecurityGroupRule, scanner.New().Scan(blocks, excludedChecksList))
+
+	var ignored []scanner.Result
+	for _, result := range scanner.New(scanner.OptionIncludeIgnored()).Scan(blocks, excludedChecksList) {
+		if result.RuleID == checks.AWSOpenIngressSecurityGroupRule {
+			ignored = append(ignored, result)
+		}
+	}
+
+	require.Len(t, ignored, 1)
+	require.NotNil(t, ignored[0].Ignored)
+	assert.Equal(t, "vendor VPN", ignored[0].Ignored.Reason)
+	assert.Equal(t, "2999-12-31", ignored[0].Ignored.Expiry)
+	assert.Equal(t, 4, ignored[0].Ignored.Range.StartLine)
+}
+
+func Test_IgnoreReasonInJSON(t *testing.T) {
+
+	results := scanner.New(scanner.OptionIncludeIgnored()).Scan(createBlocksFromSourceFile("test.tf.json", `
+{
+  "resource": {
+    "aws_security_group_rule": {
+      "my-rule": {
+        "type": "ingress",
+        "//": "tfsec:ignore:AWS006 reason=\"vendor VPN\"",
+        "cidr_blocks": ["0.0.0.0/0"]
+      }
+    }
+  }
+}
+`), excludedChecksList)
+
+	var reasons []string
+	for _, result := range results {
+		if result.RuleID == checks.AWSOpenIngressSecurityGroupRule && result.Ignored != nil {
+			reasons = append(reasons, result.Ignored.Reason)
+		}
+	}
+	assert.Equal(t, []string{"vendor VPN"}, reasons)
+}
+
+func Test_IgnoreWarnings(t *testing.T) {
+
+	blocks := createBlocksFromSource(`
+resource "aws_security_group_rule" "fixed" {
+    type        = "ingress"
+    cidr_blocks = ["10.0.0.0/8"] # tfsec:ignore:AWS006
+}
+
+resource "aws_security_group_rule" "expired" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"] # tfsec:ignore:AWS006:exp:2001-01-01
+}
+
+resource "aws_security_group_rule" "ignored" {
+    type        = "ingress"
+    # tfsec:ignore:AWS006:exp:2999-12-31 tfsec:ignore:XYZ123 tfsec:ignore:AWS006:exp:31/12/2999
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`)
+
+	tfScanner := scanner.New()
+	tfScanner.Scan(blocks, excludedChecksList)
+
+	var problems []scanner.IgnoreProblem
+	var lines []int
+	for _, warning := range tfScanner.IgnoreWarnings() {
+		problems = append(problems, warning.Problem)
+		lines = append(lines, warning.Ignore.Range.StartLine)
+	}
+	assert.Equal(t, []scanner.IgnoreProblem{
+		scanner.IgnoreUnused,
+		scanner.IgnoreExpired,
+		scanner.IgnoreUnknownCheck,
+		scanner.IgnoreBadExpiry,
+	}, problems)
+	assert.Equal(t, []int{4, 9, 14, 14}, lines)
+}
+
+func Test_IgnoreOfExcludedCheckIsUsed(t *testing.T) {
+
+	blocks := createBlocksFromSource(`
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"] # tfsec:ignore:AWS006
+}
+`)
+
+	tfScanner := scanner.New()
+	tfScanner.Scan(blocks, []string{string(checks.AWSOpenIngressSecurityGroupRule)})
+	assert.Empty(t, tfScanner.IgnoreWarnings())
+}
+
+func Test_IgnoreFile(t *testing.T) {
+
+	var tests = []struct {
+		name          string
+		source        string
+		expectIgnored bool
+	}{
+		{
+			name: "ignore at the top of the file",
+			source: `# tfsec:ignore-file:AWS006 reason="internal network"
+
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`,
+			expectIgnored: true,
+		},
+		{
+			name: "ignore after a block comment",
+			source: `/*
+  Security groups for the internal network
+*/
+// tfsec:ignore-file:*
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`,
+			expectIgnored: true,
+		},
+		{
+			name: "ignore after the first block",
+			source: `
+resource "aws_security_group_rule" "other-rule" {
+    type        = "egress"
+}
+
+# tfsec:ignore-file:AWS006
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`,
+			expectIgnored: false,
+		},
+		{
+			name: "ignore of another check",
+			source: `# tfsec:ignore-file:AWS007
+resource "aws_security_group_rule" "my-rule" {
+    type        = "ingress"
+    cidr_blocks = ["0.0.0.0/0"]
+}
+`,
+			expectIgnored: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanSource(test.source)
+			if test.expectIgnored {
+				assertCheckCode(t, "", checks.AWSOpenIngressSecurityGroupRule, results)
+			} else {
+				assertCheckCode(t, checks.AWSOpenIngressSecurityGroupRule, "", results)
+			}
+		})
+	}
+}
+
+func Test_IgnoreOnModuleCall(t *testing.T) {
+
+	path := createTestFileWithModule(`
+# tfsec:ignore:AWS001 reason="the vendor's module makes the bucket public"
+module "vendor" {
+	source = "../module"
+	acl    = "public-read"
+}
+
+module "ours" {
+	source = "../module"
+	acl    = "public-read"
+}
+`, `
+variable "acl" {}
+
+resource "aws_s3_bucket" "bucket" {
+	acl = var.acl
+}
+`)
+
+	blocks, err := parser.New().ParseDirectory(path, nil)
+	require.NoError(t, err)
+
+	tfScanner := scanner.New(scanner.OptionIncludeIgnored())
+	var reported []string
+	var ignored []string
+	for _, result := range tfScanner.Scan(blocks, excludedChecksList) {
+		if result.RuleID != checks.AWSBadBucketACL {
+			continue
+		}
+		if result.Ignored != nil {
+			ignored = append(ignored, result.Address)
+		} else {
+			reported = append(reported, result.Address)
+		}
+	}
+
+	assert.Equal(t, []string{"module.ours.aws_s3_bucket.bucket"}, reported)
+	assert.Equal(t, []string{"module.vendor.aws_s3_bucket.bucket"}, ignored)
+	assert.Empty(t, tfScanner.IgnoreWarnings())
+}
@@ -0,0 +1,119 @@
+package tfsec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_JSONConfiguration(t *testing.T) {
+
+	var tests = []struct {
+		name                  string
+		source                string
+		mustIncludeResultCode scanner.RuleID
+		mustExcludeResultCode scanner.RuleID
+	}{
+		{
+			name: "check aws_security_group_rule ingress on 0.0.0.0/0 in json",
+			source: `{
+	"resource": {
+		"aws_security_group_rule": {
+			"my-rule": {
+				"type": "ingress",
+				"cidr_blocks": ["0.0.0.0/0"]
+			}
+		}
+	}
+}`,
+			mustIncludeResultCode: checks.AWSOpenIngressSecurityGroupRule,
+		},
+		{
+			name: "check variable reference is evaluated in json",
+			source: `{
+	"variable": {
+		"blocks": {
+			"default": ["0.0.0.0/0"]
+		}
+	},
+	"resource": {
+		"aws_security_group_rule": {
+			"my-rule": {
+				"type": "ingress",
+				"cidr_blocks": "${var.blocks}"
+			}
+		}
+	}
+}`,
+			mustIncludeResultCode: checks.AWSOpenIngressSecurityGroupRule,
+		},
+		{
+			name: "check nested block in json",
+			source: `{
+	"resource": {
+		"aws_security_group": {
+			"my-group": {
+				"description": "test",
+				"ingress": [
+					{
+						"description": "test",
+						"cidr_blocks": ["0.0.0.0/0"]
+					}
+				]
+			}
+		}
+	}
+}`,
+			mustIncludeResultCode: checks.AWSOpenIngressSecurityGroupInlineRule,
+		},
+		{
+			name: "check json comment property ignores result",
+			source: `{
+	"resource": {
+		"aws_security_group_rule": {
+			"my-rule": {
+				"type": "ingress",
+				"//": "tfsec:ignore:AWS006",
+				"cidr_blocks": ["0.0.0.0/0"]
+			}
+		}
+	}
+}`,
+			mustExcludeResultCode: checks.AWSOpenIngressSecurityGroupRule,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			results := scanJSONSource(test.source)
+			assertCheckCode(t, test.mustIncludeResultCode, test.mustExcludeResultCode, results)
+		})
+	}
+
+}
+
+func Test_JSONResultRange(t *testing.T) {
+
+	results := scanJSONSource(`{
+	"resource": {
+		"problem": {
+			"uhoh": {
+				"name": "uhoh"
+			}
+		}
+	}
+}`)
+
+	var found bool
+	for _, result := range results {
+		if result.RuleID == exampleCheckCode {
+			found = true
+			assert.Equal(t, 4, result.Range.StartLine)
+			assert.Equal(t, 6, result.Range.EndLine)
+		}
+	}
+	assert.True(t, found)
+}
@@ -3,6 +3,10 @@ package tfsec
 import (
 	"testing"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
 )
@@ -33,7 +37,7 @@ resource "problem" "uhoh" {}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			path := createTestFileWithModule(test.source, test.moduleSource)
-			blocks, err := parser.New().ParseDirectory(path, nil, "")
+			blocks, err := parser.New().ParseDirectory(path, nil)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -43,3 +47,37 @@ resource "problem" "uhoh" {}
 	}
 
 }
+
+func Test_ProblemInOneOfTwoModuleCalls(t *testing.T) {
+
+	path := createTestFileWithModule(`
+module "private" {
+	source = "../module"
+	acl    = "private"
+}
+
+module "public" {
+	source = "../module"
+	acl    = "public-read"
+}
+`, `
+variable "acl" {}
+
+resource "aws_s3_bucket" "bucket" {
+	acl = var.acl
+}
+`)
+
+	blocks, err := parser.New().ParseDirectory(path, nil)
+	require.NoError(t, err)
+
+	var aclResults []scanner.Result
+	for _, result := range scanner.New().Scan(blocks, excludedChecksList) {
+		if result.RuleID == checks.AWSBadBucketACL {
+			aclResults = append(aclResults, result)
+		}
+	}
+
+	require.Len(t, aclResults, 1)
+	assert.Contains(t, aclResults[0].Description, "module.public.aws_s3_bucket.bucket")
+}
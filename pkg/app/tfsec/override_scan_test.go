@@ -0,0 +1,71 @@
+package tfsec
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/scanner"
+)
+
+func Test_ProblemIntroducedByOverrideFile(t *testing.T) {
+
+	path := createTestFile("main.tf", `
+resource "aws_s3_bucket" "my-bucket" {
+	acl = "private"
+}
+`)
+	overridePath := filepath.Join(filepath.Dir(path), "bucket_override.tf")
+	require.NoError(t, ioutil.WriteFile(overridePath, []byte(`
+resource "aws_s3_bucket" "my-bucket" {
+	acl = "public-read"
+}
+`), 0600))
+
+	blocks, err := parser.New().ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+
+	var aclResults []scanner.Result
+	for _, result := range scanner.New().Scan(blocks, excludedChecksList) {
+		if result.RuleID == checks.AWSBadBucketACL {
+			aclResults = append(aclResults, result)
+		}
+	}
+
+	require.Len(t, aclResults, 1)
+	assert.Equal(t, overridePath, aclResults[0].Range.Filename)
+	assert.Equal(t, 3, aclResults[0].Range.StartLine)
+}
+
+func Test_UnusedIgnoreInOverrideFile(t *testing.T) {
+
+	path := createTestFile("main.tf", `
+resource "aws_s3_bucket" "my-bucket" {
+	acl = "public-read"
+}
+`)
+	overridePath := filepath.Join(filepath.Dir(path), "bucket_override.tf")
+	require.NoError(t, ioutil.WriteFile(overridePath, []byte(`
+resource "aws_s3_bucket" "my-bucket" {
+	acl = "private" # tfsec:ignore:AWS001
+}
+`), 0600))
+
+	tfParser := parser.New()
+	blocks, err := tfParser.ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+
+	tfScanner := scanner.New(scanner.OptionWithSources(tfParser.Sources()))
+	tfScanner.Scan(blocks, excludedChecksList)
+
+	warnings := tfScanner.IgnoreWarnings()
+	require.Len(t, warnings, 1)
+	assert.Equal(t, scanner.IgnoreUnused, warnings[0].Problem)
+	assert.Equal(t, overridePath, warnings[0].Ignore.Range.Filename)
+	assert.Equal(t, 3, warnings[0].Ignore.Range.StartLine)
+}
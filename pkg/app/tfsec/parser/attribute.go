@@ -2,16 +2,16 @@ package parser
 
 import (
 	"github.com/hashicorp/hcl/v2"
-	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
 
 type Attribute struct {
-	hclAttribute *hclsyntax.Attribute
+	hclAttribute *hcl.Attribute
 	ctx          *hcl.EvalContext
+	sensitive    bool
 }
 
-func NewAttribute(attr *hclsyntax.Attribute, ctx *hcl.EvalContext) *Attribute {
+func NewAttribute(attr *hcl.Attribute, ctx *hcl.EvalContext) *Attribute {
 	return &Attribute{
 		hclAttribute: attr,
 		ctx:          ctx,
@@ -22,6 +22,12 @@ func (attr *Attribute) IsLiteral() bool {
 	return len(attr.hclAttribute.Expr.Variables()) == 0
 }
 
+// IsSensitive returns true if the attribute's value is sensitive: either it is the value of a variable or output marked
+// with sensitive = true, or it refers to such a value, directly or through other values.
+func (attr *Attribute) IsSensitive() bool {
+	return attr != nil && attr.sensitive
+}
+
 func (attr *Attribute) Type() cty.Type {
 	return attr.Value().Type()
 }
@@ -38,11 +44,22 @@ func (attr *Attribute) Value() cty.Value {
 }
 
 func (attr *Attribute) Range() Range {
-	return Range{
-		Filename:  attr.hclAttribute.SrcRange.Filename,
-		StartLine: attr.hclAttribute.SrcRange.Start.Line,
-		EndLine:   attr.hclAttribute.SrcRange.End.Line,
+	return newRange(attr.hclAttribute.Range)
+}
+
+// RangeOfElement returns the range of the element at the given index of a list written out in the configuration e.g.
+// the "0.0.0.0/0" in cidr_blocks = ["10.0.0.0/16", "0.0.0.0/0"]. The range of the whole attribute is returned if the
+// list is built some other way, such as from a variable.
+func (attr *Attribute) RangeOfElement(index int) Range {
+	elements, diagnostics := hcl.ExprList(attr.hclAttribute.Expr)
+	if diagnostics.HasErrors() || index < 0 || index >= len(elements) {
+		return attr.Range()
+	}
+	value := attr.Value()
+	if value.IsNull() || !value.CanIterateElements() || value.LengthInt() != len(elements) {
+		return attr.Range()
 	}
+	return newRange(elements[index].Range())
 }
 
 func (attr *Attribute) Name() string {
@@ -1,16 +1,25 @@
 package parser
 
 import (
+	"fmt"
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 type Block struct {
-	hclBlock *hcl.Block
-	ctx      *hcl.EvalContext
-	prefix   string
+	hclBlock    *hcl.Block
+	ctx         *hcl.EvalContext
+	prefix      string
+	instanceKey cty.Value
+	rootModule  string
+	// moduleCalls are the ranges of the module calls the block was evaluated through, outermost first
+	moduleCalls []Range
+	// sensitiveValues holds the addresses of the values in the block's module which are sensitive, or derived from
+	// sensitive values, e.g. var.password
+	sensitiveValues map[string]bool
 }
 
 type Blocks []*Block
@@ -25,19 +34,29 @@ func (blocks Blocks) OfType(t string) Blocks {
 	return results
 }
 
+// RemoveDuplicates removes blocks which were loaded more than once. A block in a module directory may be loaded
+// directly as well as through each call to that module, in which case only the blocks from the module calls are kept,
+// as they were evaluated with the inputs of each call.
 func (blocks Blocks) RemoveDuplicates() Blocks {
+	moduleRanges := make(map[Range]bool)
+	for _, block := range blocks {
+		if block.prefix != "" {
+			moduleRanges[block.Range()] = true
+		}
+	}
 	var filtered Blocks
+	seen := make(map[string]bool)
 	for _, block := range blocks {
-		var found bool
-		for _, current := range filtered {
-			if current.Range() == block.Range() {
-				found = true
-				break
-			}
+		if block.prefix == "" && moduleRanges[block.Range()] {
+			continue
 		}
-		if !found {
-			filtered = append(filtered, block)
+		r := block.Range()
+		key := r.String() + ":" + block.Name()
+		if seen[key] {
+			continue
 		}
+		seen[key] = true
+		filtered = append(filtered, block)
 	}
 	return filtered
 }
@@ -49,8 +68,95 @@ func NewBlock(hclBlock *hcl.Block, ctx *hcl.EvalContext) *Block {
 	}
 }
 
-func (block *Block) body() *hclsyntax.Body {
-	return block.hclBlock.Body.(*hclsyntax.Body)
+// newChild creates a block nested within this one, which shares its module's sensitive values
+func (block *Block) newChild(hclBlock *hcl.Block, ctx *hcl.EvalContext) *Block {
+	child := NewBlock(hclBlock, ctx)
+	child.sensitiveValues = block.sensitiveValues
+	return child
+}
+
+// newAttribute creates an attribute of this block, which is sensitive if the block is a variable or output marked as
+// sensitive, or if the attribute refers to a sensitive value
+func (block *Block) newAttribute(attr *hcl.Attribute) *Attribute {
+	attribute := NewAttribute(attr, block.ctx)
+	if isMarkedSensitive(block.hclBlock) {
+		attribute.sensitive = true
+		return attribute
+	}
+	for _, address := range referencedAddresses(attr.Expr) {
+		if block.sensitiveValues[address] {
+			attribute.sensitive = true
+			break
+		}
+	}
+	return attribute
+}
+
+// isMarkedSensitive returns true for variable and output blocks which set sensitive = true
+func isMarkedSensitive(block *hcl.Block) bool {
+	if block.Type != "variable" && block.Type != "output" {
+		return false
+	}
+	attributes, _, _ := bodyParts(block.Body)
+	sensitive, exists := attributes["sensitive"]
+	if !exists {
+		return false
+	}
+	val, diagnostics := sensitive.Expr.Value(nil)
+	return !diagnostics.HasErrors() && val.IsKnown() && !val.IsNull() && val.Type() == cty.Bool && val.True()
+}
+
+// RootModule returns the directory of the root module the block was evaluated as part of
+func (block *Block) RootModule() string {
+	return block.rootModule
+}
+
+// ModulePath returns the address of the module call the block was evaluated through e.g. module.network.module.vpc,
+// or an empty string for blocks of a root module
+func (block *Block) ModulePath() string {
+	return block.prefix
+}
+
+// ModuleCallRanges returns the ranges of the module blocks the block was evaluated through, starting with the call in
+// the root module, or nothing for blocks of a root module
+func (block *Block) ModuleCallRanges() []Range {
+	return block.moduleCalls
+}
+
+// Provider returns the provider configuration a resource or data source uses e.g. aws or aws.west, which is implied by
+// the resource type unless it is set with the provider argument. The name and any alias of a provider block itself
+// are returned, and an empty string for other blocks.
+func (block *Block) Provider() string {
+	switch block.Type() {
+	case "provider":
+		if len(block.Labels()) == 0 {
+			return ""
+		}
+		if alias := staticAttributeString(block.hclBlock, "alias"); alias != "" {
+			return block.Labels()[0] + "." + alias
+		}
+		return block.Labels()[0]
+	case "resource", "data":
+		if len(block.Labels()) == 0 {
+			return ""
+		}
+		if provider, exists := block.attributes()["provider"]; exists {
+			if traversal, diagnostics := hcl.AbsTraversalForExpr(provider.Expr); !diagnostics.HasErrors() {
+				var parts []string
+				for _, step := range traversal {
+					switch step := step.(type) {
+					case hcl.TraverseRoot:
+						parts = append(parts, step.Name)
+					case hcl.TraverseAttr:
+						parts = append(parts, step.Name)
+					}
+				}
+				return strings.Join(parts, ".")
+			}
+		}
+		return strings.SplitN(block.Labels()[0], "_", 2)[0]
+	}
+	return ""
 }
 
 func (block *Block) Type() string {
@@ -65,29 +171,73 @@ func (block *Block) Range() Range {
 	if block == nil || block.hclBlock == nil {
 		return Range{}
 	}
-	r := block.body().SrcRange
-	return Range{
-		Filename:  r.Filename,
-		StartLine: r.Start.Line,
-		EndLine:   r.End.Line,
-	}
+	return newRange(block.hclRange())
 }
 
-func (block *Block) GetBlock(name string) *Block {
-	if block == nil || block.hclBlock == nil {
-		return nil
+// hclRange returns the full range of the block. Native syntax bodies know their own range, but JSON bodies only
+// expose their opening and closing braces, so we have to stitch those together.
+func (block *Block) hclRange() hcl.Range {
+	switch body := block.hclBlock.Body.(type) {
+	case *hclsyntax.Body:
+		// the body itself starts at the opening brace, after the block type and labels
+		return hcl.RangeBetween(block.hclBlock.DefRange, body.SrcRange)
+	case *mergedBody:
+		return body.srcRange
 	}
-	for _, child := range block.body().Blocks {
-		if child.Type == name {
-			return NewBlock(child.AsHCLBlock(), block.ctx)
+	return hcl.RangeBetween(block.hclBlock.DefRange, block.hclBlock.Body.MissingItemRange())
+}
+
+// attributes returns all attributes defined directly within the block
+func (block *Block) attributes() hcl.Attributes {
+	if body, ok := block.hclBlock.Body.(*hclsyntax.Body); ok {
+		attributes := make(hcl.Attributes)
+		for name, attr := range body.Attributes {
+			attributes[name] = attr.AsHCLAttribute()
 		}
-		if child.Type == "dynamic" && len(child.Labels) == 1 && child.Labels[0] == name {
-			blocks := block.parseDynamicBlockResult(child)
-			if len(blocks) > 0 {
-				return blocks[0]
+		return attributes
+	}
+	// JSON bodies can't tell attributes and nested blocks apart without a schema, so nested blocks appear here too
+	attributes, _ := block.hclBlock.Body.JustAttributes()
+	delete(attributes, "dynamic")
+	return attributes
+}
+
+// childBlocks returns all nested blocks of the given type, including dynamic blocks which generate that type
+func (block *Block) childBlocks(name string) hcl.Blocks {
+	var results hcl.Blocks
+	if body, ok := block.hclBlock.Body.(*hclsyntax.Body); ok {
+		for _, child := range body.Blocks {
+			if child.Type == name || isDynamicBlockOf(child.Type, child.Labels, name) {
+				results = append(results, child.AsHCLBlock())
 			}
-			return nil
 		}
+		return results
+	}
+	// JSON bodies only reveal nested blocks when asked for them by type
+	content, _, _ := block.hclBlock.Body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{
+			{Type: name},
+			{Type: "dynamic", LabelNames: []string{"name"}},
+		},
+	})
+	if content == nil {
+		return nil
+	}
+	for _, child := range content.Blocks {
+		if child.Type == name || isDynamicBlockOf(child.Type, child.Labels, name) {
+			results = append(results, child)
+		}
+	}
+	return results
+}
+
+func isDynamicBlockOf(blockType string, labels []string, name string) bool {
+	return blockType == "dynamic" && len(labels) == 1 && labels[0] == name
+}
+
+func (block *Block) GetBlock(name string) *Block {
+	if blocks := block.GetBlocks(name); len(blocks) > 0 {
+		return blocks[0]
 	}
 	return nil
 }
@@ -97,63 +247,174 @@ func (block *Block) GetBlocks(name string) Blocks {
 		return nil
 	}
 	var results []*Block
-	for _, child := range block.body().Blocks {
+	for _, child := range block.childBlocks(name) {
 		if child.Type == name {
-			results = append(results, NewBlock(child.AsHCLBlock(), block.ctx))
-		}
-		if child.Type == "dynamic" && len(child.Labels) == 1 && child.Labels[0] == name {
-			dynamics := block.parseDynamicBlockResult(child)
-			results = append(results, dynamics...)
-
+			results = append(results, block.newChild(child, block.ctx))
+			continue
 		}
+		results = append(results, block.parseDynamicBlockResult(child)...)
 	}
 	return results
 }
 
-func (block *Block) parseDynamicBlockResult(dynamic *hclsyntax.Block) Blocks {
+// parseDynamicBlockResult generates a copy of the dynamic block's content for each element of its for_each argument.
+// Each copy is evaluated in a child context where the iterator (named after the block, or by the iterator argument)
+// holds the key and value of the element, so nested dynamic blocks can refer to their parents' iterators too.
+func (block *Block) parseDynamicBlockResult(dynamic *hcl.Block) Blocks {
 
 	var results Blocks
 
-	wrapped := NewBlock(dynamic.AsHCLBlock(), block.ctx)
+	wrapped := block.newChild(dynamic, block.ctx)
 
 	forEach := wrapped.GetAttribute("for_each")
 	if forEach == nil {
 		return nil
 	}
 
-	contentBlock := wrapped.GetBlock("content")
-	if contentBlock == nil {
+	content := wrapped.childBlocks("content")
+	if len(content) == 0 {
 		return nil
 	}
 
-	values := forEach.Value().AsValueSlice()
-	for range values {
-		clone := *contentBlock
-		results = append(results, &clone)
+	iteratorName := dynamic.Labels[0]
+	if iteratorAttr, exists := wrapped.attributes()["iterator"]; exists {
+		if name := hcl.ExprAsKeyword(iteratorAttr.Expr); name != "" {
+			iteratorName = name
+		}
+	}
+
+	forEachVal := forEach.Value()
+	if forEachVal.IsNull() || !forEachVal.CanIterateElements() {
+		return Blocks{block.newDynamicContent(content[0], iteratorName, cty.DynamicVal, cty.DynamicVal)}
+	}
+
+	for it := forEachVal.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		if forEachVal.Type().IsSetType() {
+			key = value
+		}
+		results = append(results, block.newDynamicContent(content[0], iteratorName, key, value))
 	}
 
 	return results
 }
 
+func (block *Block) newDynamicContent(content *hcl.Block, iteratorName string, key cty.Value, value cty.Value) *Block {
+	ctx := block.ctx.NewChild()
+	ctx.Variables = map[string]cty.Value{
+		iteratorName: cty.ObjectVal(map[string]cty.Value{
+			"key":   key,
+			"value": value,
+		}),
+	}
+	return block.newChild(content, ctx)
+}
+
 func (block *Block) GetAttributes() []*Attribute {
 	var results []*Attribute
 	if block == nil || block.hclBlock == nil {
 		return nil
 	}
-	for _, attr := range block.body().Attributes {
-		results = append(results, NewAttribute(attr, block.ctx))
+	for _, attr := range block.attributes() {
+		results = append(results, block.newAttribute(attr))
 	}
 	return results
 }
 
+// Values returns the attributes and nested blocks of the block as an object, in the form a block refers to itself with
+// self e.g. self.tags or self.ingress[0].cidr_blocks. Nested blocks are held in lists, and values which can't be known
+// until terraform is applied are unknown.
+func (block *Block) Values() cty.Value {
+	if block == nil || block.hclBlock == nil {
+		return cty.EmptyObjectVal
+	}
+	values := make(map[string]cty.Value)
+	for _, attribute := range block.GetAttributes() {
+		value := attribute.Value()
+		if value.Type() == cty.NilType {
+			value = cty.DynamicVal
+		}
+		values[attribute.Name()] = value
+	}
+	_, _, children := bodyParts(block.hclBlock.Body)
+	for _, child := range children {
+		name := generatedBlockType(child)
+		if _, exists := values[name]; exists {
+			continue
+		}
+		var nested []cty.Value
+		for _, nestedBlock := range block.GetBlocks(name) {
+			nested = append(nested, nestedBlock.Values())
+		}
+		values[name] = cty.EmptyTupleVal
+		if len(nested) > 0 {
+			values[name] = cty.TupleVal(nested)
+		}
+	}
+	return cty.ObjectVal(values)
+}
+
+// EnclosingAttributeRange returns the range of the attribute within the block, or any of its nested blocks, which
+// contains the given range. This finds the whole attribute when a range only covers part of it, such as a single
+// element of a list.
+func (block *Block) EnclosingAttributeRange(r Range) (Range, bool) {
+	if block == nil || block.hclBlock == nil {
+		return Range{}, false
+	}
+	return enclosingAttributeRange(block.hclBlock.Body, r)
+}
+
+func enclosingAttributeRange(body hcl.Body, r Range) (Range, bool) {
+	attributes, _, blocks := bodyParts(body)
+	for _, attribute := range attributes {
+		if attributeRange := newRange(attribute.Range); attributeRange.Contains(r) {
+			return attributeRange, true
+		}
+	}
+	for _, child := range blocks {
+		if enclosing, ok := enclosingAttributeRange(child.Body, r); ok {
+			return enclosing, true
+		}
+	}
+	return Range{}, false
+}
+
+// AttributePath returns the path of the attribute within the block, or any of its nested blocks, which contains the
+// given range e.g. ingress.cidr_blocks. An empty string is returned if the range isn't within an attribute.
+func (block *Block) AttributePath(r Range) string {
+	if block == nil || block.hclBlock == nil {
+		return ""
+	}
+	return attributePath(block.hclBlock.Body, r)
+}
+
+func attributePath(body hcl.Body, r Range) string {
+	attributes, _, blocks := bodyParts(body)
+	for name, attribute := range attributes {
+		if newRange(attribute.Range).Contains(r) {
+			return name
+		}
+	}
+	for _, child := range blocks {
+		path := attributePath(child.Body, r)
+		if path == "" {
+			continue
+		}
+		if child.Type == "dynamic" {
+			// the attributes of a dynamic block are within its content block, which is left out of the path
+			path = strings.TrimPrefix(path, "content.")
+		}
+		return generatedBlockType(child) + "." + path
+	}
+	return ""
+}
+
 func (block *Block) GetAttribute(name string) *Attribute {
 	if block == nil || block.hclBlock == nil {
 		return nil
 	}
-	for _, attr := range block.body().Attributes {
-		if attr.Name == name {
-			return NewAttribute(attr, block.ctx)
-		}
+	if attr, exists := block.attributes()[name]; exists {
+		return block.newAttribute(attr)
 	}
 	return nil
 }
@@ -166,5 +427,16 @@ func (block *Block) Name() string {
 	if block.prefix != "" {
 		prefix = block.prefix + "." + prefix
 	}
-	return prefix + strings.Join(block.Labels(), ".")
+	return prefix + strings.Join(block.Labels(), ".") + block.instanceAddress()
+}
+
+// instanceAddress returns the index of the block within its count or for_each expansion, e.g. [0] or ["prod"]
+func (block *Block) instanceAddress() string {
+	if block.instanceKey.IsNull() {
+		return ""
+	}
+	if block.instanceKey.Type() == cty.Number {
+		return fmt.Sprintf("[%s]", block.instanceKey.AsBigFloat().Text('f', -1))
+	}
+	return fmt.Sprintf("[%q]", block.instanceKey.AsString())
 }
@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// DiagnosticType describes what kind of problem a Diagnostic reports
+type DiagnosticType string
+
+const (
+	// DiagnosticParseError is reported for a file which could not be parsed, and so was not scanned
+	DiagnosticParseError DiagnosticType = "parse_error"
+	// DiagnosticEvaluationError is reported for a value which could not be fully evaluated, such as a reference cycle
+	DiagnosticEvaluationError DiagnosticType = "evaluation_error"
+)
+
+// Diagnostic is a problem found in the configuration which prevented part of it from being scanned
+type Diagnostic struct {
+	Type     DiagnosticType `json:"type"`
+	Severity string         `json:"severity"`
+	Summary  string         `json:"summary"`
+	Detail   string         `json:"detail"`
+	Range    Range          `json:"location"`
+}
+
+func newDiagnostic(diagnosticType DiagnosticType, filename string, diagnostic *hcl.Diagnostic) Diagnostic {
+	severity := "ERROR"
+	if diagnostic.Severity == hcl.DiagWarning {
+		severity = "WARNING"
+	}
+	r := Range{Filename: filename}
+	if diagnostic.Subject != nil {
+		r = newRange(*diagnostic.Subject)
+	}
+	return Diagnostic{
+		Type:     diagnosticType,
+		Severity: severity,
+		Summary:  diagnostic.Summary,
+		Detail:   diagnostic.Detail,
+		Range:    r,
+	}
+}
+
+// addParseError records that the given file could not be parsed. Any error other than hcl diagnostics is reported as
+// a single diagnostic for the whole file. Files read for every root module, such as --tfvars-file files, are only
+// reported once.
+func (parser *Parser) addParseError(filename string, err error) {
+	diagnostics, ok := err.(hcl.Diagnostics)
+	if !ok {
+		diagnostics = hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  err.Error(),
+		}}
+	}
+	var parseErrors []Diagnostic
+	for _, diagnostic := range diagnostics {
+		if diagnostic.Severity != hcl.DiagError {
+			continue
+		}
+		parseErrors = append(parseErrors, newDiagnostic(DiagnosticParseError, filename, diagnostic))
+	}
+	parser.parseErrors[filename] = parseErrors
+}
+
+func (parser *Parser) sortedParseErrors() []Diagnostic {
+	var filenames []string
+	for filename := range parser.parseErrors {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
+	var diagnostics []Diagnostic
+	for _, filename := range filenames {
+		diagnostics = append(diagnostics, parser.parseErrors[filename]...)
+	}
+	return diagnostics
+}
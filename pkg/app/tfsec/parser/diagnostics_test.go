@@ -0,0 +1,163 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UnparseableFilesAreSkipped(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+resource "cats_cat" "mittens" {
+	name = "mittens"
+}
+
+module "litter" {
+	source = "./litter"
+}
+`,
+		"broken.tf": `
+resource "cats_cat" "boots" {
+	name = 
+}
+`,
+		"unknown.tf": `
+not_a_block_type "cats" {}
+`,
+		"litter/main.tf": `
+resource "cats_kitten" "runt" {
+	name = "runt"
+}
+`,
+		"litter/broken.tf.json": `{
+	"resource": [
+}`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, block := range blocks {
+		names = append(names, block.Name())
+	}
+	assert.Equal(t, []string{"cats_cat.mittens", "module.litter", "module.litter.cats_kitten.runt"}, names)
+
+	files := make(map[string][]Diagnostic)
+	for _, diagnostic := range parser.Diagnostics() {
+		assert.Equal(t, DiagnosticParseError, diagnostic.Type)
+		assert.Equal(t, "ERROR", diagnostic.Severity)
+		assert.NotEmpty(t, diagnostic.Summary)
+		files[diagnostic.Range.Filename] = append(files[diagnostic.Range.Filename], diagnostic)
+	}
+	require.Len(t, files, 3)
+
+	broken := files[filepath.Join(dir, "broken.tf")]
+	require.Len(t, broken, 1)
+	assert.Equal(t, "Invalid expression", broken[0].Summary)
+	assert.Equal(t, 3, broken[0].Range.StartLine)
+
+	unknown := files[filepath.Join(dir, "unknown.tf")]
+	require.Len(t, unknown, 1)
+	assert.Equal(t, "Unsupported block type", unknown[0].Summary)
+	assert.Equal(t, 2, unknown[0].Range.StartLine)
+
+	assert.NotEmpty(t, files[filepath.Join(dir, "litter", "broken.tf.json")])
+}
+
+func Test_UnparseableTFVarsAreSkipped(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "name" {
+	default = "default"
+}
+
+variable "size" {
+	default = 1
+}
+
+resource "cats_cat" "mittens" {
+	name = var.name
+	size = var.size
+}
+`,
+		"terraform.tfvars": `
+name = 
+`,
+		"extra.auto.tfvars": `
+size = 3
+`,
+		"explicit.tfvars": `
+size = ,
+`,
+	})
+
+	parser := New(OptionWithTFVarsFile(filepath.Join(dir, "explicit.tfvars")))
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	assert.Equal(t, "default", resources[0].GetAttribute("name").Value().AsString())
+	size, _ := resources[0].GetAttribute("size").Value().AsBigFloat().Int64()
+	assert.Equal(t, int64(3), size)
+
+	diagnostics := parser.Diagnostics()
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, filepath.Join(dir, "explicit.tfvars"), diagnostics[0].Range.Filename)
+	assert.Equal(t, filepath.Join(dir, "terraform.tfvars"), diagnostics[1].Range.Filename)
+	for _, diagnostic := range diagnostics {
+		assert.Equal(t, DiagnosticParseError, diagnostic.Type)
+		assert.Equal(t, 2, diagnostic.Range.StartLine)
+	}
+}
+
+func Test_MissingTFVarsFileFailsParse(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+resource "cats_cat" "mittens" {}
+`,
+	})
+
+	_, err := New(OptionWithTFVarsFile(filepath.Join(dir, "missing.tfvars"))).ParseDirectory(dir, nil)
+	assert.Error(t, err)
+}
+
+func Test_UnparseableModuleManifestIsReported(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+resource "cats_cat" "mittens" {
+	name = "mittens"
+}
+
+module "vpc" {
+	source = "registry.example.com/network/vpc/aws"
+}
+`,
+		".terraform/modules/modules.json": `{"Modules":[
+	{"Key":"vpc",,}
+]}`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	require.Len(t, blocks.OfType("resource"), 1)
+	assert.Len(t, parser.UnresolvedModules(), 1)
+
+	diagnostics := parser.Diagnostics()
+	require.Len(t, diagnostics, 1)
+	assert.Equal(t, DiagnosticParseError, diagnostics[0].Type)
+	assert.Equal(t, "Invalid module manifest", diagnostics[0].Summary)
+	assert.Equal(t, filepath.Join(dir, ".terraform", "modules", "modules.json"), diagnostics[0].Range.Filename)
+	assert.Equal(t, 2, diagnostics[0].Range.StartLine)
+}
@@ -0,0 +1,388 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// evaluationNode is a single named value in a module which can be referenced by other values e.g. var.name,
+// local.name, aws_s3_bucket.logs, data.aws_iam_policy.admin, module.vpc or output.id
+type evaluationNode struct {
+	address      string
+	block        *hcl.Block
+	attribute    *hcl.Attribute // only set for local values, which are attributes of a locals block
+	references   []string
+	dependencies []int
+}
+
+// evaluationGraph orders the values of a module so that each value is evaluated after everything it refers to
+type evaluationGraph struct {
+	nodes     []*evaluationNode
+	addresses map[string][]int
+}
+
+// newEvaluationGraph creates a node for each referenceable value in the given blocks, linked to the nodes of the
+// values it refers to
+func newEvaluationGraph(blocks hcl.Blocks) *evaluationGraph {
+
+	graph := &evaluationGraph{
+		addresses: make(map[string][]int),
+	}
+
+	for _, block := range blocks {
+		switch block.Type {
+		case "locals":
+			attributes, _ := block.Body.JustAttributes()
+			for _, attribute := range attributesInOrder(attributes) {
+				name := attribute.Name
+				graph.add(&evaluationNode{
+					address:    "local." + name,
+					block:      block,
+					attribute:  attribute,
+					references: referencedAddresses(attribute.Expr),
+				})
+			}
+		case "variable":
+			if len(block.Labels) < 1 {
+				continue
+			}
+			graph.addBlock(block, "var."+block.Labels[0])
+		case "output", "provider", "module":
+			if len(block.Labels) < 1 {
+				continue
+			}
+			graph.addBlock(block, block.Type+"."+block.Labels[0])
+		case "resource":
+			if len(block.Labels) < 2 {
+				continue
+			}
+			graph.addBlock(block, block.Labels[0]+"."+block.Labels[1])
+		case "data":
+			if len(block.Labels) < 2 {
+				continue
+			}
+			graph.addBlock(block, "data."+block.Labels[0]+"."+block.Labels[1])
+		}
+	}
+
+	for _, node := range graph.nodes {
+		for _, reference := range node.references {
+			node.dependencies = append(node.dependencies, graph.addresses[reference]...)
+		}
+	}
+
+	return graph
+}
+
+func (g *evaluationGraph) addBlock(block *hcl.Block, address string) {
+	attributes, _ := block.Body.JustAttributes()
+	var references []string
+	for _, attribute := range attributesInOrder(attributes) {
+		references = append(references, referencedAddresses(attribute.Expr)...)
+	}
+	g.add(&evaluationNode{
+		address:    address,
+		block:      block,
+		references: references,
+	})
+}
+
+func (g *evaluationGraph) add(node *evaluationNode) {
+	g.addresses[node.address] = append(g.addresses[node.address], len(g.nodes))
+	g.nodes = append(g.nodes, node)
+}
+
+// attributesInOrder returns the given attributes in the order they were declared
+func attributesInOrder(attributes hcl.Attributes) []*hcl.Attribute {
+	var ordered []*hcl.Attribute
+	for _, attribute := range attributes {
+		ordered = append(ordered, attribute)
+	}
+	sort.Slice(ordered, func(i, j int) bool {
+		if ordered[i].Range.Filename != ordered[j].Range.Filename {
+			return ordered[i].Range.Filename < ordered[j].Range.Filename
+		}
+		if ordered[i].Range.Start.Byte != ordered[j].Range.Start.Byte {
+			return ordered[i].Range.Start.Byte < ordered[j].Range.Start.Byte
+		}
+		return ordered[i].Name < ordered[j].Name
+	})
+	return ordered
+}
+
+// referencedAddresses returns the address of each value the given expression refers to. References to count, each,
+// self, path and terraform are not values of the module, so are left out.
+func referencedAddresses(expr hcl.Expression) []string {
+	var addresses []string
+	for _, traversal := range expr.Variables() {
+		if address, ok := referenceAddress(traversal); ok {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+func referenceAddress(traversal hcl.Traversal) (string, bool) {
+
+	root := traversal.RootName()
+	steps := 1
+
+	switch root {
+	case "count", "each", "self", "path", "terraform":
+		return "", false
+	case "data":
+		steps = 2
+	}
+
+	parts := []string{root}
+	for _, step := range traversal[1:] {
+		if len(parts) > steps {
+			break
+		}
+		attr, ok := step.(hcl.TraverseAttr)
+		if !ok {
+			return "", false
+		}
+		parts = append(parts, attr.Name)
+	}
+	if len(parts) <= steps {
+		return "", false
+	}
+
+	return strings.Join(parts, "."), true
+}
+
+// order returns the nodes of the graph grouped so that each group only depends on the groups before it. Groups
+// containing more than one node, or a node which refers to itself, are reference cycles. Nodes are otherwise kept in
+// the order they were declared in.
+func (g *evaluationGraph) order() [][]*evaluationNode {
+
+	// Tarjan's algorithm emits each strongly connected component after every component it depends on
+	index := make([]int, len(g.nodes))
+	lowLink := make([]int, len(g.nodes))
+	onStack := make([]bool, len(g.nodes))
+	visited := make([]bool, len(g.nodes))
+	var stack []int
+	var groups [][]*evaluationNode
+	next := 0
+
+	var connect func(n int)
+	connect = func(n int) {
+		index[n], lowLink[n] = next, next
+		next++
+		visited[n] = true
+		stack = append(stack, n)
+		onStack[n] = true
+
+		for _, dependency := range g.nodes[n].dependencies {
+			if !visited[dependency] {
+				connect(dependency)
+				if lowLink[dependency] < lowLink[n] {
+					lowLink[n] = lowLink[dependency]
+				}
+			} else if onStack[dependency] && index[dependency] < lowLink[n] {
+				lowLink[n] = index[dependency]
+			}
+		}
+
+		if lowLink[n] != index[n] {
+			return
+		}
+
+		var members []int
+		for {
+			member := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[member] = false
+			members = append(members, member)
+			if member == n {
+				break
+			}
+		}
+
+		sort.Ints(members)
+		group := make([]*evaluationNode, len(members))
+		for i, member := range members {
+			group[i] = g.nodes[member]
+		}
+		groups = append(groups, group)
+	}
+
+	for n := range g.nodes {
+		if !visited[n] {
+			connect(n)
+		}
+	}
+
+	return groups
+}
+
+// isCycle returns true if the given group of nodes refer to each other
+func isCycle(group []*evaluationNode) bool {
+	if len(group) > 1 {
+		return true
+	}
+	for _, reference := range group[0].references {
+		if reference == group[0].address {
+			return true
+		}
+	}
+	return false
+}
+
+func cycleDiagnostic(group []*evaluationNode, modulePrefix string) *hcl.Diagnostic {
+	var addresses []string
+	for _, node := range group {
+		address := node.address
+		if modulePrefix != "" {
+			address = modulePrefix + "." + address
+		}
+		addresses = append(addresses, address)
+	}
+	subject := group[0].block.DefRange
+	if group[0].attribute != nil {
+		subject = group[0].attribute.Range
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Cycle in references",
+		Detail:   fmt.Sprintf("The following values refer to each other, so cannot be fully evaluated: %s", strings.Join(addresses, ", ")),
+		Subject:  &subject,
+	}
+}
+
+// moduleValues holds the values of a module as they are evaluated, and keeps an evaluation context up to date with
+// them. Only the variables which have changed since the last update are rebuilt.
+type moduleValues struct {
+	isRoot    bool
+	values    map[string]map[string]cty.Value
+	resources map[string]map[string]cty.Value
+	data      map[string]map[string]cty.Value
+	changed   map[string]bool
+}
+
+func newModuleValues(isRoot bool) *moduleValues {
+	values := &moduleValues{
+		isRoot:    isRoot,
+		values:    make(map[string]map[string]cty.Value),
+		resources: make(map[string]map[string]cty.Value),
+		data:      make(map[string]map[string]cty.Value),
+		changed:   make(map[string]bool),
+	}
+	for _, name := range []string{"var", "local", "provider", "module", "data", "output"} {
+		values.values[name] = make(map[string]cty.Value)
+		values.changed[name] = true
+	}
+	return values
+}
+
+// set stores a value of the given kind e.g. var, local, output
+func (v *moduleValues) set(kind string, name string, val cty.Value) {
+	v.values[kind][name] = val
+	v.changed[kind] = true
+}
+
+// get returns a value of the given kind e.g. var, local, output, or cty.NilVal if it hasn't been set
+func (v *moduleValues) get(kind string, name string) cty.Value {
+	return v.values[kind][name]
+}
+
+func (v *moduleValues) setResource(resourceType string, name string, val cty.Value) {
+	if v.resources[resourceType] == nil {
+		v.resources[resourceType] = make(map[string]cty.Value)
+	}
+	v.resources[resourceType][name] = val
+	v.changed["resource."+resourceType] = true
+}
+
+func (v *moduleValues) setData(dataType string, name string, val cty.Value) {
+	if v.data[dataType] == nil {
+		v.data[dataType] = make(map[string]cty.Value)
+	}
+	v.data[dataType][name] = val
+	v.changed["data"] = true
+}
+
+// contextFor returns a context holding only the given values, which are those referred to by the attributes a node
+// evaluates. Evaluating a node then takes time in proportion to the number of values it refers to, rather than to the
+// size of the module.
+func (v *moduleValues) contextFor(ctx *hcl.EvalContext, references []string) *hcl.EvalContext {
+
+	objects := make(map[string]map[string]cty.Value)
+	dataTypes := make(map[string]map[string]cty.Value)
+	add := func(objectName string, name string, val cty.Value, exists bool) {
+		if objects[objectName] == nil {
+			objects[objectName] = make(map[string]cty.Value)
+		}
+		if exists {
+			objects[objectName][name] = val
+		}
+	}
+
+	for _, reference := range references {
+		parts := strings.Split(reference, ".")
+		if parts[0] == "data" {
+			if dataTypes[parts[1]] == nil {
+				dataTypes[parts[1]] = make(map[string]cty.Value)
+			}
+			if val, exists := v.data[parts[1]][parts[2]]; exists {
+				dataTypes[parts[1]][parts[2]] = val
+			}
+			continue
+		}
+		if kind, isKind := v.values[parts[0]]; isKind {
+			val, exists := kind[parts[1]]
+			add(parts[0], parts[1], val, exists)
+			continue
+		}
+		if resources, isResourceType := v.resources[parts[0]]; isResourceType {
+			val, exists := resources[parts[1]]
+			add(parts[0], parts[1], val, exists)
+		}
+	}
+
+	scoped := ctx.NewChild()
+	scoped.Variables = make(map[string]cty.Value)
+	for objectName, values := range objects {
+		scoped.Variables[objectName] = cty.ObjectVal(values)
+	}
+	if len(dataTypes) > 0 {
+		data := make(map[string]cty.Value)
+		for dataType, values := range dataTypes {
+			data[dataType] = cty.ObjectVal(values)
+		}
+		scoped.Variables["data"] = cty.ObjectVal(data)
+	}
+	return scoped
+}
+
+// update copies any changed values into the given context
+func (v *moduleValues) update(ctx *hcl.EvalContext) {
+
+	for changed := range v.changed {
+		switch {
+		case changed == "data":
+			dataTypes := make(map[string]cty.Value)
+			for dataType, values := range v.data {
+				dataTypes[dataType] = cty.ObjectVal(values)
+			}
+			ctx.Variables["data"] = cty.ObjectVal(dataTypes)
+		case strings.HasPrefix(changed, "resource."):
+			resourceType := strings.TrimPrefix(changed, "resource.")
+			ctx.Variables[resourceType] = cty.ObjectVal(v.resources[resourceType])
+		case changed == "output" && !v.isRoot:
+			// the outputs of a module call are the attributes of module.<name>, so they are held at the top level
+			for name, val := range v.values["output"] {
+				ctx.Variables[name] = val
+			}
+		default:
+			ctx.Variables[changed] = cty.ObjectVal(v.values[changed])
+		}
+	}
+
+	v.changed = make(map[string]bool)
+}
@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_EvaluationOrder(t *testing.T) {
+
+	// each local refers to the one declared after it, so evaluating in declaration order would take one pass per local
+	var source strings.Builder
+	source.WriteString("locals {\n")
+	for i := 0; i < 50; i++ {
+		fmt.Fprintf(&source, "\tname_%d = local.name_%d\n", i, i+1)
+	}
+	source.WriteString("\tname_50 = module.store.name\n}\n")
+	source.WriteString(`
+resource "cats_cat" "mittens" {
+	name = local.name_0
+}
+
+module "store" {
+	source = "./store"
+	name   = var.name
+}
+
+variable "name" {
+	default = "mittens"
+}
+`)
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": source.String(),
+		"store/main.tf": `
+variable "name" {}
+
+output "name" {
+	value = var.name
+}
+`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, parser.Diagnostics())
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	assert.Equal(t, "mittens", resources[0].GetAttribute("name").Value().AsString())
+}
+
+func Test_LargeModuleEvaluation(t *testing.T) {
+
+	// each value is evaluated with only the values it refers to, so this takes time in proportion to the module's size
+	var source strings.Builder
+	source.WriteString("locals {\n\tname_0 = \"mittens\"\n")
+	for i := 1; i < 5000; i++ {
+		fmt.Fprintf(&source, "\tname_%d = local.name_%d\n", i, i-1)
+	}
+	source.WriteString("}\n\nresource \"cats_cat\" \"mittens\" {\n\tname = local.name_4999\n}\n")
+
+	dir := createTestDirectory(map[string]string{"main.tf": source.String()})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	assert.Equal(t, "mittens", resources[0].GetAttribute("name").Value().AsString())
+}
+
+func Test_ReferenceCycles(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+locals {
+	a = local.b
+	b = cats_cat.mittens.name
+	c = local.c
+}
+
+resource "cats_cat" "mittens" {
+	name = local.a
+}
+
+resource "cats_cat" "boots" {
+	name = "boots"
+}
+`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	diagnostics := parser.Diagnostics()
+	require.Len(t, diagnostics, 2)
+	assert.Equal(t, DiagnosticEvaluationError, diagnostics[0].Type)
+	assert.Equal(t, "Cycle in references", diagnostics[0].Summary)
+	assert.Contains(t, diagnostics[0].Detail, "local.a, local.b, cats_cat.mittens")
+	assert.Equal(t, filepath.Join(dir, "main.tf"), diagnostics[0].Range.Filename)
+	assert.Equal(t, 3, diagnostics[0].Range.StartLine)
+	assert.Contains(t, diagnostics[1].Detail, "local.c")
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 2)
+	assert.Equal(t, "boots", resources[1].GetAttribute("name").Value().AsString())
+}
+
+// createBenchmarkDirectory creates a root module with the given number of chained locals, resources and module calls,
+// which is roughly the shape of a large real-world configuration
+func createBenchmarkDirectory(size int) string {
+
+	var root strings.Builder
+	root.WriteString("variable \"prefix\" {\n\tdefault = \"bench\"\n}\n\n")
+	root.WriteString("locals {\n\tname_0 = var.prefix\n")
+	for i := 1; i < size; i++ {
+		fmt.Fprintf(&root, "\tname_%d = \"${local.name_%d}-%d\"\n", i, i-1, i)
+	}
+	root.WriteString("}\n\n")
+	for i := 0; i < size; i++ {
+		fmt.Fprintf(&root, "resource \"aws_s3_bucket\" \"bucket_%d\" {\n\tbucket = local.name_%d\n\tacl    = \"private\"\n}\n\n", i, i)
+	}
+	for i := 0; i < size/10; i++ {
+		fmt.Fprintf(&root, "module \"store_%d\" {\n\tsource = \"./store\"\n\tname   = aws_s3_bucket.bucket_%d.bucket\n}\n\n", i, i)
+	}
+
+	return createTestDirectory(map[string]string{
+		"main.tf": root.String(),
+		"store/main.tf": `
+variable "name" {}
+
+locals {
+	logs = "${var.name}-logs"
+}
+
+resource "aws_s3_bucket" "logs" {
+	bucket = local.logs
+}
+
+output "logs" {
+	value = aws_s3_bucket.logs.bucket
+}
+`,
+	})
+}
+
+func benchmarkParseDirectory(b *testing.B, size int) {
+	dir := createBenchmarkDirectory(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := New().ParseDirectory(dir, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseDirectory10(b *testing.B) {
+	benchmarkParseDirectory(b, 10)
+}
+
+func BenchmarkParseDirectory100(b *testing.B) {
+	benchmarkParseDirectory(b, 100)
+}
+
+func BenchmarkParseDirectory500(b *testing.B) {
+	benchmarkParseDirectory(b, 500)
+}
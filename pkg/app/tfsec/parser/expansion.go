@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+type expansionMode int
+
+const (
+	noExpansion expansionMode = iota
+	countExpansion
+	forEachExpansion
+)
+
+// expandBlock creates an instance of the given block for each element of its count or for_each meta-argument. Each
+// instance has its own evaluation context containing count.index, or each.key and each.value. Blocks which do not use
+// either meta-argument, or whose meta-argument cannot be evaluated yet, are returned as a single unexpanded instance.
+func expandBlock(hclBlock *hcl.Block, ctx *hcl.EvalContext) (Blocks, expansionMode) {
+
+	block := NewBlock(hclBlock, ctx)
+
+	if hclBlock.Type != "resource" && hclBlock.Type != "data" {
+		return Blocks{block}, noExpansion
+	}
+
+	if countAttr := block.GetAttribute("count"); countAttr != nil {
+		return expandCount(hclBlock, ctx, countAttr), countExpansion
+	}
+
+	if forEachAttr := block.GetAttribute("for_each"); forEachAttr != nil {
+		return expandForEach(hclBlock, ctx, forEachAttr), forEachExpansion
+	}
+
+	return Blocks{block}, noExpansion
+}
+
+func expandCount(hclBlock *hcl.Block, ctx *hcl.EvalContext, countAttr *Attribute) Blocks {
+
+	countVal := countAttr.Value()
+	// like terraform, a count given as a string such as "2" is converted to a number
+	if countVal != cty.NilVal && countVal.IsWhollyKnown() && !countVal.IsNull() {
+		if converted, err := convert.Convert(countVal, cty.Number); err == nil {
+			countVal = converted
+		}
+	}
+	if countVal.IsNull() || !countVal.IsKnown() || countVal.Type() != cty.Number {
+		return Blocks{newInstance(hclBlock, ctx, cty.NilVal, map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{
+				"index": cty.UnknownVal(cty.Number),
+			}),
+		})}
+	}
+
+	count, _ := countVal.AsBigFloat().Int64()
+
+	var instances Blocks
+	for i := int64(0); i < count; i++ {
+		index := cty.NumberIntVal(i)
+		instances = append(instances, newInstance(hclBlock, ctx, index, map[string]cty.Value{
+			"count": cty.ObjectVal(map[string]cty.Value{
+				"index": index,
+			}),
+		}))
+	}
+	return instances
+}
+
+func expandForEach(hclBlock *hcl.Block, ctx *hcl.EvalContext, forEachAttr *Attribute) Blocks {
+
+	forEachVal := forEachAttr.Value()
+	if forEachVal.IsNull() || !forEachVal.CanIterateElements() {
+		return Blocks{newInstance(hclBlock, ctx, cty.NilVal, map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{
+				"key":   cty.UnknownVal(cty.String),
+				"value": cty.DynamicVal,
+			}),
+		})}
+	}
+
+	var instances Blocks
+	for it := forEachVal.ElementIterator(); it.Next(); {
+		key, value := it.Element()
+		// sets and lists of strings use each element as both the key and the value
+		if !forEachVal.Type().IsMapType() && !forEachVal.Type().IsObjectType() {
+			key = value
+		}
+		if !key.IsKnown() || key.IsNull() || key.Type() != cty.String {
+			continue
+		}
+		instances = append(instances, newInstance(hclBlock, ctx, key, map[string]cty.Value{
+			"each": cty.ObjectVal(map[string]cty.Value{
+				"key":   key,
+				"value": value,
+			}),
+		}))
+	}
+	return instances
+}
+
+func newInstance(hclBlock *hcl.Block, ctx *hcl.EvalContext, key cty.Value, variables map[string]cty.Value) *Block {
+	instanceCtx := ctx.NewChild()
+	instanceCtx.Variables = variables
+	block := NewBlock(hclBlock, instanceCtx)
+	block.instanceKey = key
+	return block
+}
+
+// readInstanceValues returns the values of the given block. For blocks using count this is a tuple of the values of
+// each instance, and for blocks using for_each it is an object keyed by each.key.
+func (parser *Parser) readInstanceValues(ctx *hcl.EvalContext, hclBlock *hcl.Block) cty.Value {
+
+	instances, mode := expandBlock(hclBlock, ctx)
+
+	switch mode {
+	case countExpansion:
+		if len(instances) == 1 && instances[0].instanceKey.IsNull() {
+			return cty.DynamicVal
+		}
+		var values []cty.Value
+		for _, instance := range instances {
+			values = append(values, parser.readValues(instance.ctx, hclBlock))
+		}
+		if len(values) == 0 {
+			return cty.EmptyTupleVal
+		}
+		return cty.TupleVal(values)
+	case forEachExpansion:
+		if len(instances) == 1 && instances[0].instanceKey.IsNull() {
+			return cty.DynamicVal
+		}
+		values := make(map[string]cty.Value)
+		for _, instance := range instances {
+			values[instance.instanceKey.AsString()] = parser.readValues(instance.ctx, hclBlock)
+		}
+		return cty.ObjectVal(values)
+	default:
+		return parser.readValues(ctx, hclBlock)
+	}
+}
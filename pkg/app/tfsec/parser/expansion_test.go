@@ -0,0 +1,178 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CountExpansion(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "names" {
+	default = ["first", "second"]
+}
+
+resource "cats_cat" "litter" {
+	count = length(var.names)
+	name  = var.names[count.index]
+}
+
+resource "cats_kitten" "runt" {
+	parent = cats_cat.litter[1].name
+}
+
+resource "cats_cat" "none" {
+	count = 0
+	name  = "nobody"
+}
+`,
+	})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 3)
+
+	assert.Equal(t, "cats_cat.litter[0]", resources[0].Name())
+	assert.Equal(t, "first", resources[0].GetAttribute("name").Value().AsString())
+	assert.Equal(t, "cats_cat.litter[1]", resources[1].Name())
+	assert.Equal(t, "second", resources[1].GetAttribute("name").Value().AsString())
+
+	assert.Equal(t, "cats_kitten.runt", resources[2].Name())
+	assert.Equal(t, "second", resources[2].GetAttribute("parent").Value().AsString())
+}
+
+func Test_StringCountExpansion(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "instances" {
+	default = "2"
+}
+
+resource "cats_cat" "litter" {
+	count = var.instances
+	name  = "cat-${count.index}"
+}
+
+resource "cats_cat" "invalid" {
+	count = "two"
+}
+`,
+	})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, block := range blocks.OfType("resource") {
+		names = append(names, block.Name())
+	}
+	assert.Equal(t, []string{"cats_cat.litter[0]", "cats_cat.litter[1]", "cats_cat.invalid"}, names)
+}
+
+func Test_ForEachExpansion(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+locals {
+	environments = {
+		dev  = "private"
+		prod = "public-read"
+	}
+}
+
+resource "cats_cat" "by_env" {
+	for_each = local.environments
+	name     = each.key
+	acl      = each.value
+}
+
+resource "cats_cat" "by_name" {
+	for_each = toset(["mittens"])
+	name     = each.value
+}
+
+resource "cats_kitten" "runt" {
+	acl = cats_cat.by_env["prod"].acl
+}
+`,
+	})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 4)
+
+	assert.Equal(t, `cats_cat.by_env["dev"]`, resources[0].Name())
+	assert.Equal(t, "dev", resources[0].GetAttribute("name").Value().AsString())
+	assert.Equal(t, "private", resources[0].GetAttribute("acl").Value().AsString())
+	assert.Equal(t, `cats_cat.by_env["prod"]`, resources[1].Name())
+	assert.Equal(t, "public-read", resources[1].GetAttribute("acl").Value().AsString())
+
+	assert.Equal(t, `cats_cat.by_name["mittens"]`, resources[2].Name())
+	assert.Equal(t, "mittens", resources[2].GetAttribute("name").Value().AsString())
+
+	assert.Equal(t, "public-read", resources[3].GetAttribute("acl").Value().AsString())
+}
+
+func Test_DynamicBlockIterators(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+locals {
+	groups = {
+		cats = ["mittens", "boots"]
+		dogs = ["rex"]
+	}
+}
+
+resource "cats_shelter" "shelter" {
+	dynamic "room" {
+		for_each = local.groups
+		iterator = group
+		content {
+			species = group.key
+			dynamic "pet" {
+				for_each = group.value
+				content {
+					name    = pet.value
+					index   = pet.key
+					species = group.key
+				}
+			}
+		}
+	}
+}
+`,
+	})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+
+	rooms := resources[0].GetBlocks("room")
+	require.Len(t, rooms, 2)
+	assert.Equal(t, "cats", rooms[0].GetAttribute("species").Value().AsString())
+	assert.Equal(t, "dogs", rooms[1].GetAttribute("species").Value().AsString())
+
+	cats := rooms[0].GetBlocks("pet")
+	require.Len(t, cats, 2)
+	assert.Equal(t, "mittens", cats[0].GetAttribute("name").Value().AsString())
+	assert.Equal(t, "boots", cats[1].GetAttribute("name").Value().AsString())
+	assert.Equal(t, "cats", cats[1].GetAttribute("species").Value().AsString())
+	index, _ := cats[1].GetAttribute("index").Value().AsBigFloat().Int64()
+	assert.Equal(t, int64(1), index)
+
+	dogs := rooms[1].GetBlocks("pet")
+	require.Len(t, dogs, 1)
+	assert.Equal(t, "rex", dogs[0].GetAttribute("name").Value().AsString())
+	assert.Equal(t, "dogs", dogs[0].GetAttribute("species").Value().AsString())
+}
@@ -0,0 +1,124 @@
+package parser
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// UnresolvedModule describes a module call which could not be scanned because its source was not found on disk
+type UnresolvedModule struct {
+	Key    string `json:"key"`
+	Source string `json:"source"`
+	Range  Range  `json:"location"`
+	Reason string `json:"reason"`
+}
+
+// moduleManifest is the list of installed modules written to .terraform/modules/modules.json by terraform init
+type moduleManifest struct {
+	rootDir string
+	Modules []moduleManifestEntry `json:"Modules"`
+}
+
+type moduleManifestEntry struct {
+	Key     string `json:"Key"`
+	Source  string `json:"Source"`
+	Version string `json:"Version"`
+	Dir     string `json:"Dir"`
+}
+
+// moduleManifestPath returns the path of the module manifest for the root module in the given directory
+func moduleManifestPath(rootDir string) string {
+	return filepath.Join(rootDir, ".terraform", "modules", "modules.json")
+}
+
+// loadModuleManifest reads the module manifest for the root module in the given directory, if terraform init has been
+// run there. A nil manifest is returned if there is none. A manifest which isn't valid JSON is reported with the
+// position of the problem, as hcl diagnostics.
+func loadModuleManifest(rootDir string) (*moduleManifest, error) {
+
+	filename := moduleManifestPath(rootDir)
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var manifest moduleManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, manifestDiagnostics(filename, data, err)
+	}
+	manifest.rootDir = rootDir
+
+	return &manifest, nil
+}
+
+// manifestDiagnostics converts an error from decoding the module manifest into hcl diagnostics, with the position of
+// the problem if known
+func manifestDiagnostics(filename string, data []byte, err error) hcl.Diagnostics {
+	diagnostic := &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "Invalid module manifest",
+		Detail:   err.Error(),
+	}
+	var offset int64 = -1
+	switch jsonErr := err.(type) {
+	case *json.SyntaxError:
+		offset = jsonErr.Offset
+	case *json.UnmarshalTypeError:
+		offset = jsonErr.Offset
+	}
+	if offset >= 0 && offset <= int64(len(data)) {
+		pos := hcl.Pos{Line: 1, Column: 1, Byte: int(offset)}
+		for _, char := range string(data[:offset]) {
+			if char == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column++
+			}
+		}
+		diagnostic.Subject = &hcl.Range{Filename: filename, Start: pos, End: pos}
+	}
+	return hcl.Diagnostics{diagnostic}
+}
+
+// dirForKey returns the directory the module with the given key was installed to. Keys are the names of each module
+// call from the root module, joined with dots e.g. "vpc.subnets".
+func (m *moduleManifest) dirForKey(key string) (string, bool) {
+	if m == nil {
+		return "", false
+	}
+	for _, module := range m.Modules {
+		if module.Key != key {
+			continue
+		}
+		if filepath.IsAbs(module.Dir) {
+			return module.Dir, true
+		}
+		return filepath.Join(m.rootDir, module.Dir), true
+	}
+	return "", false
+}
+
+func isLocalSource(source string) bool {
+	return strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../")
+}
+
+func sortUnresolvedModules(modules map[string]UnresolvedModule) []UnresolvedModule {
+	var results []UnresolvedModule
+	for _, module := range modules {
+		results = append(results, module)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Key < results[j].Key
+	})
+	return results
+}
@@ -0,0 +1,335 @@
+package parser
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// isOverrideFile returns true for files which terraform merges into the other files of a module, rather than loading
+// as configuration in their own right: override.tf, *_override.tf and their JSON equivalents.
+func isOverrideFile(filename string) bool {
+	name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(filename), ".json"), ".tf")
+	return name == "override" || strings.HasSuffix(name, "_override")
+}
+
+// applyOverrides merges the blocks from override files into the matching blocks of a module, following terraform's
+// override rules:
+//
+//   - each attribute in an override block replaces the attribute of the same name in the original block
+//   - each type of nested block in an override block replaces all nested blocks of that type in the original block,
+//     except for lifecycle blocks, which are merged attribute by attribute
+//   - each local value in an override locals block replaces the local value of the same name
+//
+// Override blocks must be given in the order terraform loads them, which is the lexical order of their filenames.
+func applyOverrides(blocks hcl.Blocks, overrides hcl.Blocks) hcl.Blocks {
+
+	merged := append(hcl.Blocks{}, blocks...)
+
+OVERRIDE:
+	for _, override := range overrides {
+
+		if override.Type == "locals" {
+			merged = overrideLocals(merged, override)
+			continue
+		}
+
+		for i, base := range merged {
+			if isOverrideOf(base, override) {
+				merged[i] = mergeBlocks(base, override)
+				continue OVERRIDE
+			}
+		}
+
+		// terraform rejects overrides with nothing to override, but we'd rather scan them than ignore them
+		merged = append(merged, override)
+	}
+
+	return merged
+}
+
+func isOverrideOf(base *hcl.Block, override *hcl.Block) bool {
+	if base.Type != override.Type || len(base.Labels) != len(override.Labels) {
+		return false
+	}
+	for i, label := range base.Labels {
+		if override.Labels[i] != label {
+			return false
+		}
+	}
+	if base.Type == "provider" {
+		return staticAttributeString(base, "alias") == staticAttributeString(override, "alias")
+	}
+	return true
+}
+
+// overrideLocals replaces the original value of each local value set in the override with the override's value,
+// wherever the original is defined. Local values which aren't defined anywhere else are added as they are.
+func overrideLocals(blocks hcl.Blocks, override *hcl.Block) hcl.Blocks {
+
+	attributes, bodies, _ := bodyParts(override.Body)
+
+	var unmatched []string
+ATTRIBUTE:
+	for name := range attributes {
+		for i, base := range blocks {
+			if base.Type != "locals" {
+				continue
+			}
+			if baseAttributes, _, _ := bodyParts(base.Body); baseAttributes[name] != nil {
+				blocks[i] = mergeBlocks(base, singleAttributeBlock(override, name, attributes[name], bodies[name]))
+				continue ATTRIBUTE
+			}
+		}
+		unmatched = append(unmatched, name)
+	}
+
+	if len(unmatched) > 0 {
+		remaining := &mergedBody{
+			attributes:      make(hcl.Attributes),
+			attributeBodies: make(map[string]hcl.Body),
+			srcRange:        NewBlock(override, nil).hclRange(),
+		}
+		for _, name := range unmatched {
+			remaining.attributes[name] = attributes[name]
+			remaining.attributeBodies[name] = bodies[name]
+		}
+		blocks = append(blocks, &hcl.Block{
+			Type:      override.Type,
+			Body:      remaining,
+			DefRange:  override.DefRange,
+			TypeRange: override.TypeRange,
+		})
+	}
+
+	return blocks
+}
+
+func singleAttributeBlock(block *hcl.Block, name string, attribute *hcl.Attribute, body hcl.Body) *hcl.Block {
+	return &hcl.Block{
+		Type: block.Type,
+		Body: &mergedBody{
+			attributes:      hcl.Attributes{name: attribute},
+			attributeBodies: map[string]hcl.Body{name: body},
+			srcRange:        NewBlock(block, nil).hclRange(),
+		},
+		DefRange:  block.DefRange,
+		TypeRange: block.TypeRange,
+	}
+}
+
+// mergeBlocks returns a copy of base with the contents of override merged in. The merged block keeps the range of
+// the base block, but each attribute keeps the range of the file it came from.
+func mergeBlocks(base *hcl.Block, override *hcl.Block) *hcl.Block {
+
+	attributes, bodies, blocks := bodyParts(base.Body)
+	overrideAttributes, overrideBodies, overrideBlocks := bodyParts(override.Body)
+
+	baseBlockTypes := make(map[string]bool)
+	for _, block := range blocks {
+		baseBlockTypes[generatedBlockType(block)] = true
+	}
+
+	replacedTypes := make(map[string]bool)
+	for _, block := range overrideBlocks {
+		replacedTypes[generatedBlockType(block)] = true
+	}
+
+	for name, attribute := range overrideAttributes {
+		body := overrideBodies[name]
+		if _, isNative := body.(*hclsyntax.Body); !isNative && baseBlockTypes[name] {
+			// a JSON property which replaces nested blocks, rather than an attribute
+			content, _, _ := body.PartialContent(&hcl.BodySchema{
+				Blocks: []hcl.BlockHeaderSchema{{Type: name}},
+			})
+			if content != nil {
+				overrideBlocks = append(overrideBlocks, content.Blocks...)
+			}
+			replacedTypes[name] = true
+			continue
+		}
+		attributes[name] = attribute
+		bodies[name] = body
+	}
+
+	for blockType := range replacedTypes {
+		// JSON properties in the base which were really nested blocks
+		if _, isNative := bodies[blockType].(*hclsyntax.Body); attributes[blockType] != nil && !isNative {
+			delete(attributes, blockType)
+			delete(bodies, blockType)
+		}
+	}
+
+	var mergedBlocks hcl.Blocks
+	for _, block := range blocks {
+		if replacedTypes[generatedBlockType(block)] && block.Type != "lifecycle" {
+			continue
+		}
+		mergedBlocks = append(mergedBlocks, block)
+	}
+
+OVERRIDE:
+	for _, block := range overrideBlocks {
+		if block.Type == "lifecycle" {
+			for i, existing := range mergedBlocks {
+				if existing.Type == "lifecycle" {
+					mergedBlocks[i] = mergeBlocks(existing, block)
+					continue OVERRIDE
+				}
+			}
+		}
+		mergedBlocks = append(mergedBlocks, block)
+	}
+
+	return &hcl.Block{
+		Type:   base.Type,
+		Labels: base.Labels,
+		Body: &mergedBody{
+			attributes:      attributes,
+			attributeBodies: bodies,
+			blocks:          mergedBlocks,
+			srcRange:        NewBlock(base, nil).hclRange(),
+		},
+		DefRange:    base.DefRange,
+		TypeRange:   base.TypeRange,
+		LabelRanges: base.LabelRanges,
+	}
+}
+
+// generatedBlockType returns the type of block the given nested block produces, which for dynamic blocks is the label
+func generatedBlockType(block *hcl.Block) string {
+	if block.Type == "dynamic" && len(block.Labels) == 1 {
+		return block.Labels[0]
+	}
+	return block.Type
+}
+
+// bodyParts splits a body into its attributes and nested blocks, along with the body each attribute came from. The
+// returned collections are copies, and can be modified freely.
+func bodyParts(body hcl.Body) (hcl.Attributes, map[string]hcl.Body, hcl.Blocks) {
+
+	attributes := make(hcl.Attributes)
+	bodies := make(map[string]hcl.Body)
+	var blocks hcl.Blocks
+
+	switch b := body.(type) {
+	case *hclsyntax.Body:
+		for name, attribute := range b.Attributes {
+			attributes[name] = attribute.AsHCLAttribute()
+			bodies[name] = b
+		}
+		for _, block := range b.Blocks {
+			blocks = append(blocks, block.AsHCLBlock())
+		}
+	case *mergedBody:
+		for name, attribute := range b.attributes {
+			attributes[name] = attribute
+			bodies[name] = b.attributeBodies[name]
+		}
+		blocks = append(blocks, b.blocks...)
+	default:
+		// JSON bodies can't tell attributes and nested blocks apart, so anything other than dynamic blocks is
+		// treated as an attribute until a nested block of the same type is asked for
+		justAttributes, _ := body.JustAttributes()
+		for name, attribute := range justAttributes {
+			if name == "dynamic" {
+				continue
+			}
+			attributes[name] = attribute
+			bodies[name] = body
+		}
+		content, _, _ := body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{{Type: "dynamic", LabelNames: []string{"name"}}},
+		})
+		if content != nil {
+			blocks = append(blocks, content.Blocks...)
+		}
+	}
+
+	return attributes, bodies, blocks
+}
+
+func staticAttributeString(block *hcl.Block, name string) string {
+	attributes, _, _ := bodyParts(block.Body)
+	attribute, exists := attributes[name]
+	if !exists {
+		return ""
+	}
+	val, diagnostics := attribute.Expr.Value(nil)
+	if diagnostics.HasErrors() || val.IsNull() || !val.IsKnown() || val.Type() != cty.String {
+		return ""
+	}
+	return val.AsString()
+}
+
+// mergedBody is the body of a block which has had one or more override files merged into it
+type mergedBody struct {
+	attributes hcl.Attributes
+	// attributeBodies holds the body each attribute came from, as JSON bodies can only reveal nested blocks
+	// when asked for them by type
+	attributeBodies map[string]hcl.Body
+	blocks          hcl.Blocks
+	srcRange        hcl.Range
+}
+
+func (b *mergedBody) Content(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Diagnostics) {
+	content, _, diagnostics := b.PartialContent(schema)
+	return content, diagnostics
+}
+
+func (b *mergedBody) PartialContent(schema *hcl.BodySchema) (*hcl.BodyContent, hcl.Body, hcl.Diagnostics) {
+
+	content := &hcl.BodyContent{
+		Attributes:       make(hcl.Attributes),
+		MissingItemRange: b.MissingItemRange(),
+	}
+
+	for _, attributeSchema := range schema.Attributes {
+		if attribute, exists := b.attributes[attributeSchema.Name]; exists {
+			content.Attributes[attributeSchema.Name] = attribute
+		}
+	}
+
+	for _, block := range b.blocks {
+		for _, blockSchema := range schema.Blocks {
+			if block.Type == blockSchema.Type && len(block.Labels) == len(blockSchema.LabelNames) {
+				content.Blocks = append(content.Blocks, block)
+				break
+			}
+		}
+	}
+
+	for _, blockSchema := range schema.Blocks {
+		body, exists := b.attributeBodies[blockSchema.Type]
+		if _, isNative := body.(*hclsyntax.Body); !exists || isNative {
+			continue
+		}
+		jsonContent, _, _ := body.PartialContent(&hcl.BodySchema{
+			Blocks: []hcl.BlockHeaderSchema{blockSchema},
+		})
+		if jsonContent != nil {
+			content.Blocks = append(content.Blocks, jsonContent.Blocks...)
+		}
+	}
+
+	return content, b, nil
+}
+
+func (b *mergedBody) JustAttributes() (hcl.Attributes, hcl.Diagnostics) {
+	attributes := make(hcl.Attributes)
+	for name, attribute := range b.attributes {
+		attributes[name] = attribute
+	}
+	return attributes, nil
+}
+
+func (b *mergedBody) MissingItemRange() hcl.Range {
+	return hcl.Range{
+		Filename: b.srcRange.Filename,
+		Start:    b.srcRange.End,
+		End:      b.srcRange.End,
+	}
+}
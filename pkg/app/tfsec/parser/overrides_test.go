@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_OverrideFiles(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "colour" {
+	default = "black"
+}
+
+locals {
+	age  = 3
+	name = "mittens"
+}
+
+resource "cats_cat" "cat" {
+	colour = var.colour
+	name   = local.name
+	age    = local.age
+
+	toy {
+		name = "ball"
+	}
+
+	toy {
+		name = "mouse"
+	}
+
+	bed {
+		size = "small"
+	}
+
+	lifecycle {
+		prevent_destroy = true
+	}
+}
+`,
+		"override.tf": `
+variable "colour" {
+	default = "ginger"
+}
+
+locals {
+	name = "boots"
+}
+`,
+		"cat_override.tf": `
+resource "cats_cat" "cat" {
+	toy {
+		name = "string"
+	}
+
+	lifecycle {
+		create_before_destroy = true
+	}
+}
+`,
+		"z_override.tf.json": `{
+	"resource": {
+		"cats_cat": {
+			"cat": {
+				"age": 4
+			}
+		}
+	}
+}`,
+	})
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	cat := resources[0]
+
+	assert.Equal(t, filepath.Join(dir, "main.tf"), cat.Range().Filename)
+
+	assert.Equal(t, "ginger", cat.GetAttribute("colour").Value().AsString())
+	assert.Equal(t, filepath.Join(dir, "main.tf"), cat.GetAttribute("colour").Range().Filename)
+
+	assert.Equal(t, "boots", cat.GetAttribute("name").Value().AsString())
+
+	age := cat.GetAttribute("age")
+	require.NotNil(t, age)
+	ageVal, _ := age.Value().AsBigFloat().Int64()
+	assert.Equal(t, int64(4), ageVal)
+	assert.Equal(t, filepath.Join(dir, "z_override.tf.json"), age.Range().Filename)
+
+	toys := cat.GetBlocks("toy")
+	require.Len(t, toys, 1)
+	assert.Equal(t, "string", toys[0].GetAttribute("name").Value().AsString())
+	assert.Equal(t, filepath.Join(dir, "cat_override.tf"), toys[0].Range().Filename)
+
+	assert.Len(t, cat.GetBlocks("bed"), 1)
+
+	lifecycle := cat.GetBlock("lifecycle")
+	require.NotNil(t, lifecycle)
+	assert.True(t, lifecycle.GetAttribute("prevent_destroy").Value().True())
+	assert.True(t, lifecycle.GetAttribute("create_before_destroy").Value().True())
+}
+
+func Test_OverrideFileNames(t *testing.T) {
+	assert.True(t, isOverrideFile("/tmp/override.tf"))
+	assert.True(t, isOverrideFile("/tmp/override.tf.json"))
+	assert.True(t, isOverrideFile("/tmp/s3_override.tf"))
+	assert.True(t, isOverrideFile("/tmp/s3_override.tf.json"))
+	assert.False(t, isOverrideFile("/tmp/main.tf"))
+	assert.False(t, isOverrideFile("/tmp/overrides.tf"))
+	assert.False(t, isOverrideFile("/tmp/override_s3.tf"))
+}
@@ -4,94 +4,149 @@ import (
 	"fmt"
 	"io/ioutil"
 	"path/filepath"
+	"sort"
 	"strings"
 
-	"github.com/hashicorp/hcl/v2/hclsyntax"
-
 	"github.com/zclconf/go-cty/cty"
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
 )
 
-const maxContextIterations = 32
-
 // Parser is a tool for parsing terraform templates at a given file system location
 type Parser struct {
-	hclParser *hclparse.Parser
-	files     map[string]bool
+	hclParser         *hclparse.Parser
+	files             map[string]bool
+	variableSources   []variableSource
+	rootTFVarsFiles   func(rootModule string) []string
+	moduleKey         string
+	sensitiveInputs   map[string]bool
+	sensitiveValues   map[string]bool
+	modulePaths       []string
+	moduleBlocks      map[string]hcl.Blocks
+	parseErrors       map[string][]Diagnostic
+	unresolvedModules []UnresolvedModule
+	diagnostics       []Diagnostic
+	sources           *Sources
+	isExcluded        func(path string) bool
+	// moduleCalls are the ranges of the module calls the module being parsed was called through, outermost first
+	moduleCalls []Range
+}
+
+// Option configures optional behaviour of a Parser
+type Option func(parser *Parser)
+
+// OptionWithExcludedPaths stops the files and directories for which isExcluded returns true from being parsed at all
+func OptionWithExcludedPaths(isExcluded func(path string) bool) Option {
+	return func(parser *Parser) {
+		parser.isExcluded = isExcluded
+	}
+}
+
+// excluded returns true if the given file or directory has been excluded from the scan
+func (parser *Parser) excluded(path string) bool {
+	return parser.isExcluded != nil && parser.isExcluded(path)
 }
 
 // New creates a new Parser
-func New() *Parser {
-	return &Parser{
-		hclParser: hclparse.NewParser(),
-		files:     make(map[string]bool),
+func New(options ...Option) *Parser {
+	parser := &Parser{
+		hclParser:    hclparse.NewParser(),
+		files:        make(map[string]bool),
+		moduleBlocks: make(map[string]hcl.Blocks),
+		parseErrors:  make(map[string][]Diagnostic),
+		sources:      NewSources(),
+	}
+	for _, option := range options {
+		option(parser)
 	}
+	return parser
 }
 
 type ParseResult struct {
 	Blocks
 	cty.Value
+	sensitiveOutputs bool
 }
 
-func (parser *Parser) readTFVars(filename string) (map[string]cty.Value, error) {
-
-	inputVars := make(map[string]cty.Value)
-
-	if filename == "" {
-		return inputVars, nil
-	}
+// ParseDirectory parses all terraform files within a given directory and its subdirectories. Each root module found
+// is evaluated separately, so variables and locals from one root module never affect another.
+func (parser *Parser) ParseDirectory(path string, excludedDirectories []string) (Blocks, error) {
 
-	src, err := ioutil.ReadFile(filename)
+	roots, err := parser.FindRootModules(path, excludedDirectories)
 	if err != nil {
 		return nil, err
 	}
 
-	tfvars, _ := hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
-	attrs, _ := tfvars.Body.JustAttributes()
+	unresolvedModules := make(map[string]UnresolvedModule)
+	parser.diagnostics = nil
 
-	for _, attr := range attrs {
-		inputVars[attr.Name], _ = attr.Expr.Value(&hcl.EvalContext{})
+	var allBlocks Blocks
+	for _, root := range roots {
+		blocks, err := parser.parseRootModule(root, unresolvedModules)
+		if err != nil {
+			return nil, err
+		}
+		allBlocks = append(allBlocks, blocks...)
 	}
 
-	return inputVars, nil
+	parser.unresolvedModules = sortUnresolvedModules(unresolvedModules)
+	return allBlocks, nil
 }
 
-// ParseDirectory recursively parses all terraform files within a given directory
-func (parser *Parser) ParseDirectory(path string, excludedDirectories []string, tfvarsPath string) (Blocks, error) {
-
-	parseCache := newParseCache()
-	if err := parser.recursivelyParseDirectory(path, parseCache, excludedDirectories); err != nil {
-		return nil, err
+func (parser *Parser) parseRootModule(path string, unresolvedModules map[string]UnresolvedModule) (Blocks, error) {
+	// without a manifest, installed modules are reported as unresolved, but the rest of the root module is scanned
+	// without a manifest, installed modules are reported as unresolved, and the rest of the root module is still scanned
+	manifest, err := loadModuleManifest(path)
+	if err != nil {
+		parser.addParseError(moduleManifestPath(path), err)
 	}
 
-	var blocks hcl.Blocks
-
-	for _, file := range parser.hclParser.Files() {
-		fileBlocks, err := parser.parseFile(file)
-		if err != nil {
-			return nil, err
-		}
-		blocks = append(blocks, fileBlocks...)
+	blocks, err := parser.loadModule(path)
+	if err != nil {
+		return nil, err
 	}
 
-	inputVars, err := parser.readTFVars(tfvarsPath)
+	inputVars, err := parser.loadInputVariables(path, blocks)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO add .tfvars values to inputVars
+	parseCache := newParseCache(manifest)
+	parser.modulePaths = []string{path}
 
-	allBlocks, _ := parser.buildEvaluationContext(
+	rootBlocks, _ := parser.buildEvaluationContext(
 		blocks,
 		path,
 		inputVars,
 		true,
 		parseCache,
-		excludedDirectories,
 	)
-	return allBlocks.RemoveDuplicates(), nil
+
+	for key, module := range parseCache.unresolvedModules {
+		unresolvedModules[path+":"+key] = module
+	}
+	for _, diagnostic := range parseCache.sortedDiagnostics() {
+		parser.diagnostics = append(parser.diagnostics, newDiagnostic(DiagnosticEvaluationError, path, diagnostic))
+	}
+
+	rootBlocks = rootBlocks.RemoveDuplicates()
+	for _, block := range rootBlocks {
+		block.rootModule = path
+	}
+	return rootBlocks, nil
+}
+
+// UnresolvedModules returns the module calls found by the last call to ParseDirectory which could not be scanned
+func (parser *Parser) UnresolvedModules() []UnresolvedModule {
+	return parser.unresolvedModules
+}
+
+// Diagnostics returns the problems which prevented part of the configuration from being scanned: configuration files,
+// tfvars files and module manifests which could not be parsed, and so were skipped, followed by values which could not
+// be evaluated during the last call to ParseDirectory, such as values which refer to each other in a cycle
+func (parser *Parser) Diagnostics() []Diagnostic {
+	return append(parser.sortedParseErrors(), parser.diagnostics...)
 }
 
 func (parser *Parser) parseFile(file *hcl.File) (hcl.Blocks, error) {
@@ -108,141 +163,285 @@ func (parser *Parser) parseFile(file *hcl.File) (hcl.Blocks, error) {
 	return contents.Blocks, nil
 }
 
-func (parser *Parser) recursivelyParseDirectory(path string, pc parseCache, excludedDirectories []string) error {
+// parseDirectory parses the terraform files in a single directory, without descending into subdirectories. This is
+// how terraform loads a module. Files which cannot be parsed are recorded as parse errors and skipped.
+func (parser *Parser) parseDirectory(path string) error {
 
 	files, err := ioutil.ReadDir(path)
 	if err != nil {
 		return err
 	}
-FILE:
+
 	for _, file := range files {
-		if strings.HasPrefix(file.Name(), ".") { //ignore dotfiles (including .terraform!)
+		if file.IsDir() || strings.HasPrefix(file.Name(), ".") {
 			continue
 		}
 		fullPath := filepath.Join(path, file.Name())
-		if exists := parser.files[fullPath]; exists {
+		if exists := parser.files[fullPath]; exists || parser.excluded(fullPath) {
 			continue
 		}
-
 		parser.files[fullPath] = true
-		if file.IsDir() {
+		if err := parser.parseConfigFile(fullPath); err != nil {
+			parser.addParseError(fullPath, err)
+		}
+	}
 
-			for _, excluded := range excludedDirectories {
-				if fullPath == excluded {
-					continue FILE
-				}
-			}
+	return nil
+}
 
-			// We want local files to be loaded as needed by modules, but
-			// we want to defend against directories being loaded multiple times.
-			if pc.hasSeenPath(fullPath) {
-				continue
-			}
-			pc.addPath(fullPath)
+// parseConfigFile parses the given file if it is a terraform configuration file, in either native or JSON syntax
+func (parser *Parser) parseConfigFile(fullPath string) error {
+	if !isConfigFile(fullPath) {
+		return nil
+	}
+	contents, err := ioutil.ReadFile(fullPath)
+	if err != nil {
+		return err
+	}
+	_, err = parser.parseSource(parser.hclParser, fullPath, contents)
+	return err
+}
 
-			if err := parser.recursivelyParseDirectory(fullPath, pc, excludedDirectories); err != nil {
-				return err
-			}
-		} else if strings.HasSuffix(file.Name(), ".tf") {
-			_, diagnostics := parser.hclParser.ParseHCLFile(fullPath)
-			if diagnostics != nil && diagnostics.HasErrors() {
-				return diagnostics
-			}
-		}
+// parseSource parses the contents of a terraform configuration file, in either native or JSON syntax, and records them
+// in the parser's sources
+func (parser *Parser) parseSource(hclParser *hclparse.Parser, filename string, contents []byte) (*hcl.File, error) {
+	parser.sources.Add(filename, contents)
+	var file *hcl.File
+	var diagnostics hcl.Diagnostics
+	if strings.HasSuffix(filename, ".tf.json") {
+		file, diagnostics = hclParser.ParseJSON(contents, filename)
+	} else {
+		file, diagnostics = hclParser.ParseHCL(contents, filename)
 	}
+	if diagnostics != nil && diagnostics.HasErrors() {
+		return nil, diagnostics
+	}
+	return file, nil
+}
 
-	return nil
+// ParseSource parses terraform configuration which isn't on disk, such as code generated by another tool, as a root
+// module of its own. The filename, which should be absolute, is used to report results and tells native syntax from
+// JSON. Its contents can be looked up in Sources, so ignore comments and code snippets work as they do for files.
+func (parser *Parser) ParseSource(filename string, contents []byte) (Blocks, error) {
+
+	// the source is parsed on its own, as the parser's files are kept by name and the same name may be used again
+	file, err := parser.parseSource(hclparse.NewParser(), filename, contents)
+	if err != nil {
+		return nil, err
+	}
+	hclBlocks, err := parser.parseFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Dir(filename)
+	parser.moduleBlocks[dir] = hclBlocks
+	parser.diagnostics = nil
+
+	unresolvedModules := make(map[string]UnresolvedModule)
+	blocks, err := parser.parseRootModule(dir, unresolvedModules)
+	if err != nil {
+		return nil, err
+	}
+	parser.unresolvedModules = sortUnresolvedModules(unresolvedModules)
+	return blocks, nil
 }
 
-// BuildEvaluationContext creates an *hcl.EvalContext by parsing values for all terraform variables (where available) then interpolating values into resource, local and data blocks until all possible values can be constructed
+// Sources returns the contents of the files the parser has read, which are shared with the scanner and formatters
+func (parser *Parser) Sources() *Sources {
+	return parser.sources
+}
+
+// buildEvaluationContext creates an *hcl.EvalContext containing the values of all variables, locals, resources, data
+// sources, outputs and module calls in the given blocks. Each value is evaluated once, after the values it refers to.
 func (parser *Parser) buildEvaluationContext(
 	blocks hcl.Blocks,
 	path string,
 	inputVars map[string]cty.Value,
 	isRoot bool,
 	pc parseCache,
-	excludedDirectories []string,
 ) (Blocks, *hcl.EvalContext) {
 	ctx := &hcl.EvalContext{
 		Variables: make(map[string]cty.Value),
 		Functions: Functions(path),
 	}
 
-	ctx.Variables["module"] = cty.ObjectVal(make(map[string]cty.Value))
-
+	values := newModuleValues(isRoot)
 	moduleBlocks := make(map[string]Blocks)
+	parser.sensitiveValues = make(map[string]bool)
 
-	for i := 0; i < maxContextIterations; i++ {
+	for _, group := range newEvaluationGraph(blocks).order() {
+		if isCycle(group) {
+			pc.addDiagnostic(cycleDiagnostic(group, parser.modulePrefix()))
+		}
+		for _, node := range group {
+			parser.evaluateNode(values.contextFor(ctx, node.references), node, values, moduleBlocks, inputVars, pc)
+			if parser.isSensitiveNode(node) {
+				parser.sensitiveValues[node.address] = true
+				parser.recordSecrets(node, values)
+			}
+		}
+	}
+	values.update(ctx)
 
-		ctx.Variables["var"] = parser.getValuesByBlockType(ctx, blocks, "variable", inputVars)
-		ctx.Variables["local"] = parser.getValuesByBlockType(ctx, blocks, "locals", nil)
-		ctx.Variables["provider"] = parser.getValuesByBlockType(ctx, blocks, "provider", nil)
-		resources := parser.getValuesByBlockType(ctx, blocks, "resource", nil)
-		for key, resource := range resources.AsValueMap() {
-			ctx.Variables[key] = resource
+	var localBlocks []*Block
+	for _, block := range blocks {
+		instances, _ := expandBlock(block, ctx)
+		for _, instance := range instances {
+			instance.prefix = parser.modulePrefix()
+			instance.moduleCalls = parser.moduleCalls
+			instance.sensitiveValues = parser.sensitiveValues
 		}
-		ctx.Variables["data"] = parser.getValuesByBlockType(ctx, blocks, "data", nil)
+		localBlocks = append(localBlocks, instances...)
+	}
 
-		if isRoot {
-			ctx.Variables["output"] = parser.getValuesByBlockType(ctx, blocks, "output", nil)
-		} else {
-			outputs := parser.getValuesByBlockType(ctx, blocks, "output", nil)
-			for key, val := range outputs.AsValueMap() {
-				ctx.Variables[key] = val
-			}
+	// blocks from module calls are already prefixed with their full module path
+	for _, block := range blocks.OfType("module") {
+		if len(block.Labels) > 0 {
+			localBlocks = append(localBlocks, moduleBlocks[block.Labels[0]]...)
 		}
+	}
 
-		for _, moduleBlock := range blocks.OfType("module") {
-			if len(moduleBlock.Labels) == 0 {
-				continue
-			}
-			moduleMap := ctx.Variables["module"].AsValueMap()
-			if moduleMap == nil {
-				moduleMap = make(map[string]cty.Value)
-			}
-			moduleName := moduleBlock.Labels[0]
+	return localBlocks, ctx
+}
 
-			moduleBlocks[moduleName], moduleMap[moduleName] = parser.parseModuleBlock(moduleBlock, ctx, path, pc, excludedDirectories) // todo return parsed blocks here too
-			ctx.Variables["module"] = cty.ObjectVal(moduleMap)
+// evaluateNode evaluates a single value of a module, storing it in values
+func (parser *Parser) evaluateNode(
+	ctx *hcl.EvalContext,
+	node *evaluationNode,
+	values *moduleValues,
+	moduleBlocks map[string]Blocks,
+	inputVars map[string]cty.Value,
+	pc parseCache,
+) {
+	block := node.block
+
+	switch block.Type {
+	case "locals":
+		val, _ := node.attribute.Expr.Value(ctx)
+		values.set("local", node.attribute.Name, val)
+	case "variable": // variables are special in that their value comes from the "default" attribute
+		attributes, _ := block.Body.JustAttributes()
+		if attributes == nil {
+			return
+		}
+		if override, exists := inputVars[block.Labels[0]]; exists {
+			values.set("var", block.Labels[0], override)
+		} else if def, exists := attributes["default"]; exists {
+			val, _ := def.Expr.Value(ctx)
+			values.set("var", block.Labels[0], val)
+		}
+	case "output":
+		attributes, _ := block.Body.JustAttributes()
+		if attributes == nil {
+			return
+		}
+		if def, exists := attributes["value"]; exists {
+			val, _ := def.Expr.Value(ctx)
+			values.set("output", block.Labels[0], val)
 		}
+	case "provider":
+		values.set("provider", block.Labels[0], parser.readValues(ctx, block))
+	case "resource":
+		values.setResource(block.Labels[0], block.Labels[1], parser.readInstanceValues(ctx, block))
+	case "data":
+		values.setData(block.Labels[0], block.Labels[1], parser.readInstanceValues(ctx, block))
+	case "module":
+		result := parser.parseModuleBlock(block, ctx, pc)
+		moduleBlocks[block.Labels[0]] = result.Blocks
+		val := result.Value
+		if val.Type() == cty.NilType {
+			// the module could not be evaluated, so its outputs are unknown
+			val = cty.DynamicVal
+		}
+		values.set("module", block.Labels[0], val)
+		if result.sensitiveOutputs {
+			parser.sensitiveValues[node.address] = true
+		}
+	}
+}
 
-		// todo check of ctx has changed since last iteration - break if not
+// isSensitiveNode returns true if the given value is sensitive: it is a variable or output marked as sensitive, a
+// variable whose input refers to a sensitive value in the calling module, or it refers to a sensitive value itself.
+// Values are evaluated after everything they refer to, so sensitivity carries through any chain of references.
+func (parser *Parser) isSensitiveNode(node *evaluationNode) bool {
+	if isMarkedSensitive(node.block) {
+		return true
+	}
+	if node.block.Type == "variable" && parser.sensitiveInputs[node.block.Labels[0]] {
+		return true
 	}
+	for _, reference := range node.references {
+		if parser.sensitiveValues[reference] {
+			return true
+		}
+	}
+	return false
+}
 
-	var localBlocks []*Block
-	for _, block := range blocks {
-		localBlocks = append(localBlocks, NewBlock(block, ctx))
+// recordSecrets adds the value of a sensitive variable, local or output to the parser's sources, so that it is masked
+// wherever the configuration is shown, even in results which don't know it is sensitive
+func (parser *Parser) recordSecrets(node *evaluationNode, values *moduleValues) {
+	parts := strings.SplitN(node.address, ".", 2)
+	switch parts[0] {
+	case "var", "local", "output":
+		parser.sources.AddSecrets(stringValues(values.get(parts[0], parts[1]))...)
 	}
+}
 
-	for moduleName, blocks := range moduleBlocks {
-		for _, block := range blocks {
-			block.prefix = fmt.Sprintf("module.%s", moduleName)
-			localBlocks = append(localBlocks, block)
-		}
+// stringValues returns the known strings within a value, including those nested in collections and objects
+func stringValues(val cty.Value) []string {
+	if val == cty.NilVal || !val.IsWhollyKnown() || val.IsNull() {
+		return nil
+	}
+	if val.Type() == cty.String {
+		return []string{val.AsString()}
+	}
+	if !val.CanIterateElements() {
+		return nil
+	}
+	var strs []string
+	for it := val.ElementIterator(); it.Next(); {
+		_, element := it.Element()
+		strs = append(strs, stringValues(element)...)
 	}
+	return strs
+}
 
-	return localBlocks, ctx
+// modulePrefix returns the address of the module call being parsed, e.g. module.vpc.module.subnets, or an empty
+// string for the root module
+func (parser *Parser) modulePrefix() string {
+	if parser.moduleKey == "" {
+		return ""
+	}
+	return "module." + strings.Join(strings.Split(parser.moduleKey, "."), ".module.")
 }
 
+// parseModuleBlock evaluates a module call. Each call is evaluated with its own inputs, so calling the same module
+// twice with different arguments produces two separate sets of blocks.
 func (parser *Parser) parseModuleBlock(
 	block *hcl.Block,
 	parentContext *hcl.EvalContext,
-	rootPath string,
 	pc parseCache,
-	excludedDirectories []string,
-) (Blocks, cty.Value) {
+) ParseResult {
 
 	if len(block.Labels) == 0 {
-		return nil, cty.NilVal
+		return ParseResult{}
 	}
 
 	inputVars := make(map[string]cty.Value)
+	sensitiveInputs := make(map[string]bool)
 
 	var source string
 	attrs, _ := block.Body.JustAttributes()
 	for _, attr := range attrs {
 
 		inputVars[attr.Name], _ = attr.Expr.Value(parentContext)
+		for _, address := range referencedAddresses(attr.Expr) {
+			if parser.sensitiveValues[address] {
+				sensitiveInputs[attr.Name] = true
+			}
+		}
 
 		if attr.Name == "source" {
 			sourceVal, _ := attr.Expr.Value(parentContext)
@@ -253,53 +452,67 @@ func (parser *Parser) parseModuleBlock(
 	}
 
 	if source == "" {
-		return nil, cty.NilVal
+		return ParseResult{}
 	}
 
-	if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
-		// TODO support module registries/github etc.
-		return nil, cty.NilVal
+	moduleKey := block.Labels[0]
+	if parser.moduleKey != "" {
+		moduleKey = parser.moduleKey + "." + moduleKey
 	}
 
-	path := filepath.Join(rootPath, source)
-	if result, ok := pc.lookupResult(path); ok {
-		return result.Blocks, result.Value
+	var path string
+	if isLocalSource(source) {
+		// local sources are relative to the directory of the file containing the module call
+		path = filepath.Join(filepath.Dir(block.DefRange.Filename), source)
+	} else if installedPath, ok := pc.manifest.dirForKey(moduleKey); ok {
+		// registry, git and other remote modules can only be scanned once terraform init has downloaded them
+		path = installedPath
+	} else {
+		pc.addUnresolvedModule(moduleKey, source, block, "module is not installed - run 'terraform init' to download it")
+		return ParseResult{}
 	}
 
-	// We need to respect the module's path if it's local to the filesystem.
-	// If the `rootPath` != `modulePath` then this means that we're not
-	// parsing this module from the correct working directory, and so
-	// parsing will break. In that case, reset the path to the path known
-	// to the module so that local paths will work as expected.
-	modulePath := filepath.Dir(block.DefRange.Filename)
-	if rootPath != modulePath {
-		path = modulePath
+	for _, callerPath := range parser.modulePaths {
+		if callerPath == path {
+			pc.addUnresolvedModule(moduleKey, source, block, "module calls itself recursively")
+			return ParseResult{}
+		}
 	}
 
-	subParser := New()
-
-	if err := subParser.recursivelyParseDirectory(path, pc, excludedDirectories); err != nil {
-		return nil, cty.NilVal
+	inputs := cty.ObjectVal(inputVars)
+	if result, ok := pc.lookupResult(moduleKey, inputs); ok {
+		return result
 	}
 
-	var blocks []*hcl.Block
-
-	for _, file := range subParser.hclParser.Files() {
-		fileBlocks, err := subParser.parseFile(file)
-		if err != nil {
-			return nil, cty.NilVal
-		}
-		blocks = append(blocks, fileBlocks...)
+	blocks, err := parser.loadModule(path)
+	if err != nil {
+		pc.addUnresolvedModule(moduleKey, source, block, err.Error())
+		return ParseResult{}
 	}
 
-	childModules, ctx := subParser.buildEvaluationContext(blocks, path, inputVars, false, pc, excludedDirectories)
+	subParser := New()
+	subParser.sources = parser.sources
+	subParser.moduleKey = moduleKey
+	subParser.moduleCalls = append(append([]Range{}, parser.moduleCalls...), NewBlock(block, nil).Range())
+	subParser.moduleBlocks = parser.moduleBlocks
+	subParser.parseErrors = parser.parseErrors
+	subParser.modulePaths = append(append([]string{}, parser.modulePaths...), path)
+	subParser.sensitiveInputs = sensitiveInputs
+
+	childModules, ctx := subParser.buildEvaluationContext(blocks, path, inputVars, false, pc)
 	parseResult := ParseResult{
-		Blocks: childModules.RemoveDuplicates(),
+		Blocks: childModules,
 		Value:  cty.ObjectVal(ctx.Variables),
 	}
+	for address := range subParser.sensitiveValues {
+		if strings.HasPrefix(address, "output.") {
+			// module outputs are only tracked as a whole, so one sensitive output makes them all sensitive
+			parseResult.sensitiveOutputs = true
+		}
+	}
 
-	pc.storeResult(path, parseResult)
-	return parseResult.Blocks, parseResult.Value
+	pc.storeResult(moduleKey, inputs, parseResult)
+	return parseResult
 }
 
 // returns true if all evaluations were successful
@@ -320,107 +533,124 @@ func (parser *Parser) readValues(ctx *hcl.EvalContext, block *hcl.Block) cty.Val
 	return cty.ObjectVal(values)
 }
 
-// returns true if all evaluations were successful
-func (parser *Parser) getValuesByBlockType(ctx *hcl.EvalContext, blocks hcl.Blocks, blockType string, inputVars map[string]cty.Value) cty.Value {
-
-	blocksOfType := blocks.OfType(blockType)
-	values := make(map[string]cty.Value)
-
-	for _, block := range blocksOfType {
+// parseCache holds the state shared by all module calls while a root module is
+// evaluated: the results of each module call, the module manifest written by
+// terraform init, any module calls which could not be resolved and any
+// problems found during evaluation.
+type parseCache struct {
+	results           map[string]moduleResult
+	manifest          *moduleManifest
+	unresolvedModules map[string]UnresolvedModule
+	diagnostics       map[string]*hcl.Diagnostic
+}
 
-		switch block.Type {
-		case "variable": // variables are special in that their value comes from the "default" attribute
-			if len(block.Labels) < 1 {
-				continue
-			}
-			attributes, _ := block.Body.JustAttributes()
-			if attributes == nil {
-				continue
-			}
-			if override, exists := inputVars[block.Labels[0]]; exists {
-				values[block.Labels[0]] = override
-			} else if def, exists := attributes["default"]; exists {
-				values[block.Labels[0]], _ = def.Expr.Value(ctx)
-			}
-		case "output":
-			if len(block.Labels) < 1 {
-				continue
-			}
-			attributes, _ := block.Body.JustAttributes()
-			if attributes == nil {
-				continue
-			}
-			if def, exists := attributes["value"]; exists {
-				values[block.Labels[0]], _ = def.Expr.Value(ctx)
-			}
-		case "locals":
-			for key, val := range parser.readValues(ctx, block).AsValueMap() {
-				values[key] = val
-			}
-		case "provider", "module":
-			if len(block.Labels) < 1 {
-				continue
-			}
-			values[block.Labels[0]] = parser.readValues(ctx, block)
-		case "resource", "data":
+// moduleResult is the evaluated result of a module call, along with the inputs it was evaluated with
+type moduleResult struct {
+	inputs cty.Value
+	ParseResult
+}
 
-			if len(block.Labels) < 2 {
-				continue
-			}
+func newParseCache(manifest *moduleManifest) parseCache {
+	return parseCache{
+		results:           make(map[string]moduleResult),
+		manifest:          manifest,
+		unresolvedModules: make(map[string]UnresolvedModule),
+		diagnostics:       make(map[string]*hcl.Diagnostic),
+	}
+}
 
-			blockMap, ok := values[block.Labels[0]]
-			if !ok {
-				values[block.Labels[0]] = cty.ObjectVal(make(map[string]cty.Value))
-				blockMap = values[block.Labels[0]]
-			}
+// lookupResult returns the result of a previous evaluation of the given module call, as long as it was evaluated with
+// the same inputs. Calls are keyed by their path from the root module e.g. "vpc.subnets".
+func (p parseCache) lookupResult(moduleKey string, inputs cty.Value) (ParseResult, bool) {
+	result, ok := p.results[moduleKey]
+	if !ok || !result.inputs.RawEquals(inputs) {
+		return ParseResult{}, false
+	}
+	return result.ParseResult, true
+}
 
-			valueMap := blockMap.AsValueMap()
-			if valueMap == nil {
-				valueMap = make(map[string]cty.Value)
-			}
+func (p parseCache) storeResult(moduleKey string, inputs cty.Value, result ParseResult) {
+	p.results[moduleKey] = moduleResult{
+		inputs:      inputs,
+		ParseResult: result,
+	}
+}
 
-			valueMap[block.Labels[1]] = parser.readValues(ctx, block)
-			values[block.Labels[0]] = cty.ObjectVal(valueMap)
-		}
+// loadModule parses the files of the module in the given directory. Modules are only parsed once, no matter how many
+// times they are called.
+func (parser *Parser) loadModule(path string) (hcl.Blocks, error) {
 
+	if blocks, ok := parser.moduleBlocks[path]; ok {
+		return blocks, nil
 	}
 
-	return cty.ObjectVal(values)
+	moduleParser := New()
+	moduleParser.sources = parser.sources
+	moduleParser.isExcluded = parser.isExcluded
+	if err := moduleParser.parseDirectory(path); err != nil {
+		return nil, err
+	}
 
-}
+	files := moduleParser.hclParser.Files()
+	var filenames []string
+	for filename := range files {
+		filenames = append(filenames, filename)
+	}
+	sort.Strings(filenames)
 
-// pathBreaker is a set of known paths to allow us to implement circuit breaking
-// so that we can defend against infinity recursion in specific module
-// sourcing circumstances.
+	var blocks hcl.Blocks
+	var overrides hcl.Blocks
+	for _, filename := range filenames {
+		if _, failed := moduleParser.parseErrors[filename]; failed {
+			continue
+		}
+		fileBlocks, err := moduleParser.parseFile(files[filename])
+		if err != nil {
+			moduleParser.addParseError(filename, err)
+			continue
+		}
+		if isOverrideFile(filename) {
+			overrides = append(overrides, fileBlocks...)
+		} else {
+			blocks = append(blocks, fileBlocks...)
+		}
+	}
 
-type parseCache struct {
-	visitedPaths map[string]struct{}
-	results      map[string]ParseResult
-}
+	blocks = applyOverrides(blocks, overrides)
 
-func newParseCache() parseCache {
-	return parseCache{
-		visitedPaths: make(map[string]struct{}),
-		results:      make(map[string]ParseResult),
+	for filename, diagnostics := range moduleParser.parseErrors {
+		parser.parseErrors[filename] = diagnostics
 	}
+	parser.moduleBlocks[path] = blocks
+	return blocks, nil
 }
 
-// add adds a new, now, known path to our path circuit breaker.
-func (p parseCache) addPath(path string) {
-	p.visitedPaths[path] = struct{}{}
-}
-
-// hasSeen returns a boolean denoting if we've seen the given path before.
-func (p parseCache) hasSeenPath(path string) bool {
-	_, ok := p.visitedPaths[path]
-	return ok
+// addUnresolvedModule records a module call which could not be scanned. Modules are keyed by their call path from the
+// root module e.g. "vpc.subnets", which is how they are sorted when reported.
+func (p parseCache) addUnresolvedModule(key string, source string, block *hcl.Block, reason string) {
+	p.unresolvedModules[key] = UnresolvedModule{
+		Key:    key,
+		Source: source,
+		Range:  NewBlock(block, nil).Range(),
+		Reason: reason,
+	}
 }
 
-func (p parseCache) lookupResult(fullPath string) (ParseResult, bool) {
-	result, ok := p.results[fullPath]
-	return result, ok
+// addDiagnostic records a problem found during evaluation. A module called more than once reports the same problem
+// each time, so problems are keyed by their location and detail.
+func (p parseCache) addDiagnostic(diagnostic *hcl.Diagnostic) {
+	p.diagnostics[diagnostic.Subject.String()+":"+diagnostic.Detail] = diagnostic
 }
 
-func (p parseCache) storeResult(fullPath string, result ParseResult) {
-	p.results[fullPath] = result
+func (p parseCache) sortedDiagnostics() hcl.Diagnostics {
+	var keys []string
+	for key := range p.diagnostics {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var diagnostics hcl.Diagnostics
+	for _, key := range keys {
+		diagnostics = append(diagnostics, p.diagnostics[key])
+	}
+	return diagnostics
 }
@@ -47,7 +47,7 @@ data "cats_cat" "the-cats-mother" {
 
 `)
 
-	blocks, err := parser.ParseDirectory(filepath.Dir(path), nil, "")
+	blocks, err := parser.ParseDirectory(filepath.Dir(path), nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -99,6 +99,121 @@ data "cats_cat" "the-cats-mother" {
 	assert.Equal(t, "boots", dataBlocks[0].GetAttribute("name").Value().AsString())
 }
 
+func Test_JSONParsing(t *testing.T) {
+	parser := New()
+
+	path := createTestFile("test.tf.json", `{
+	"variable": {
+		"cats_mother": {
+			"default": "boots"
+		}
+	},
+	"resource": {
+		"cats_cat": {
+			"mittens": {
+				"name": "${var.cats_mother}",
+				"special": true,
+				"collar": {
+					"colour": "red"
+				}
+			}
+		}
+	}
+}`)
+
+	blocks, err := parser.ParseDirectory(filepath.Dir(path), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables := blocks.OfType("variable")
+	require.Len(t, variables, 1)
+	assert.Equal(t, "cats_mother", variables[0].Labels()[0])
+
+	resourceBlocks := blocks.OfType("resource")
+	require.Len(t, resourceBlocks, 1)
+	resource := resourceBlocks[0]
+	assert.Equal(t, "cats_cat.mittens", resource.Name())
+	assert.Equal(t, "boots", resource.GetAttribute("name").Value().AsString())
+	assert.True(t, resource.GetAttribute("special").Value().True())
+	assert.Equal(t, 9, resource.Range().StartLine)
+	assert.Equal(t, 15, resource.Range().EndLine)
+
+	collar := resource.GetBlock("collar")
+	require.NotNil(t, collar)
+	colour := collar.GetAttribute("colour")
+	require.NotNil(t, colour)
+	assert.Equal(t, "red", colour.Value().AsString())
+	assert.Equal(t, 13, colour.Range().StartLine)
+}
+
+func Test_BlockValues(t *testing.T) {
+	parser := New()
+
+	path := createTestFile("test.tf", `
+variable "ports" {
+	default = [22, 80]
+}
+
+resource "cats_cat" "mittens" {
+	name = "mittens"
+	id   = cats_owner.bob.id
+	dynamic "toy" {
+		for_each = var.ports
+		content {
+			size = toy.value
+		}
+	}
+	collar {
+		colour = "red"
+	}
+}`)
+
+	blocks, err := parser.ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	values := resources[0].Values()
+
+	assert.Equal(t, "mittens", values.GetAttr("name").AsString())
+	assert.False(t, values.GetAttr("id").IsKnown())
+	assert.Equal(t, "red", values.GetAttr("collar").Index(cty.NumberIntVal(0)).GetAttr("colour").AsString())
+	toys := values.GetAttr("toy")
+	require.Equal(t, 2, toys.LengthInt())
+	assert.True(t, toys.Index(cty.NumberIntVal(1)).GetAttr("size").RawEquals(cty.NumberIntVal(80)))
+}
+
+func Test_AttributePath(t *testing.T) {
+	parser := New()
+
+	path := createTestFile("test.tf", `
+resource "cats_cat" "mittens" {
+	name = "mittens"
+	collar {
+		colour = "red"
+	}
+	dynamic "toy" {
+		for_each = ["ball"]
+		content {
+			kind = toy.value
+		}
+	}
+}`)
+
+	blocks, err := parser.ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	resource := resources[0]
+
+	assert.Equal(t, "name", resource.AttributePath(resource.GetAttribute("name").Range()))
+	assert.Equal(t, "collar.colour", resource.AttributePath(resource.GetBlock("collar").GetAttribute("colour").Range()))
+	assert.Equal(t, "toy.kind", resource.AttributePath(resource.GetBlock("toy").GetAttribute("kind").Range()))
+	assert.Equal(t, "", resource.AttributePath(resource.Range()))
+}
+
 func Test_Modules(t *testing.T) {
 
 	path := createTestFileWithModule(`
@@ -125,7 +240,7 @@ output "result" {
 
 	parser := New()
 
-	blocks, err := parser.ParseDirectory(path, nil, "")
+	blocks, err := parser.ParseDirectory(path, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -176,7 +291,7 @@ output "result" {
 
 	parser := New()
 
-	blocks, err := parser.ParseDirectory(path, nil, "")
+	blocks, err := parser.ParseDirectory(path, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -245,3 +360,180 @@ func createTestFileWithModule(contents string, moduleContents string, moduleName
 
 	return rootPath
 }
+
+func Test_InstalledRemoteModules(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+module "vpc" {
+	source  = "registry.example.com/network/vpc/aws"
+	version = "1.0.0"
+	name    = "main"
+}
+
+module "missing" {
+	source = "git::https://example.com/missing.git"
+}
+
+output "result" {
+	value = module.vpc.result
+}
+`,
+		".terraform/modules/modules.json": `{"Modules":[
+	{"Key":"","Source":"","Dir":"."},
+	{"Key":"vpc","Source":"registry.example.com/network/vpc/aws","Version":"1.0.0","Dir":".terraform/modules/vpc"},
+	{"Key":"vpc.subnets","Source":"./modules/subnets","Dir":".terraform/modules/vpc/modules/subnets"}
+]}`,
+		".terraform/modules/vpc/main.tf": `
+variable "name" {}
+
+module "subnets" {
+	source = "./modules/subnets"
+}
+
+resource "cats_network" "vpc" {
+	name = var.name
+}
+
+output "result" {
+	value = var.name
+}
+`,
+		".terraform/modules/vpc/modules/subnets/main.tf": `
+resource "cats_subnet" "subnet" {
+	name = "subnet"
+}
+`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	var names []string
+	for _, block := range blocks {
+		if block.Type() == "resource" {
+			names = append(names, block.Name())
+		}
+	}
+	assert.Contains(t, names, "module.vpc.cats_network.vpc")
+	assert.Contains(t, names, "module.vpc.module.subnets.cats_subnet.subnet")
+
+	outputs := blocks.OfType("output")
+	require.Len(t, outputs, 1)
+	assert.Equal(t, "main", outputs[0].GetAttribute("value").Value().AsString())
+
+	unresolved := parser.UnresolvedModules()
+	require.Len(t, unresolved, 1)
+	assert.Equal(t, "missing", unresolved[0].Key)
+	assert.Equal(t, "git::https://example.com/missing.git", unresolved[0].Source)
+	assert.Equal(t, 8, unresolved[0].Range.StartLine)
+}
+
+func Test_ModuleCalledWithDifferentInputs(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main/main.tf": `
+module "first" {
+	source = "../cat"
+	name   = "mittens"
+}
+
+module "second" {
+	source = "../cat"
+	name   = "boots"
+}
+`,
+		"cat/main.tf": `
+variable "name" {}
+
+module "kitten" {
+	source = "../kitten"
+	parent = var.name
+}
+
+resource "cats_cat" "cat" {
+	name = var.name
+}
+`,
+		"kitten/main.tf": `
+variable "parent" {}
+
+resource "cats_kitten" "kitten" {
+	parent = var.parent
+}
+`,
+	})
+
+	blocks, err := New().ParseDirectory(filepath.Join(dir, "main"), nil)
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, block := range blocks {
+		switch block.Type() {
+		case "resource":
+			attr := block.GetAttribute("name")
+			if attr == nil {
+				attr = block.GetAttribute("parent")
+			}
+			values[block.Name()] = attr.Value().AsString()
+		}
+	}
+
+	assert.Equal(t, map[string]string{
+		"module.first.cats_cat.cat":                      "mittens",
+		"module.first.module.kitten.cats_kitten.kitten":  "mittens",
+		"module.second.cats_cat.cat":                     "boots",
+		"module.second.module.kitten.cats_kitten.kitten": "boots",
+	}, values)
+}
+
+func Test_ColumnRanges(t *testing.T) {
+
+	path := createTestFile("test.tf", `resource "cats_cat" "mittens" {
+	toys = ["ball", var.toy]
+	names = split(",", "mittens,boots")
+}
+`)
+
+	blocks, err := New().ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+	require.Len(t, blocks, 1)
+
+	blockRange := blocks[0].Range()
+	assert.Equal(t, 1, blockRange.StartLine)
+	assert.Equal(t, 1, blockRange.StartColumn)
+	assert.Equal(t, 0, blockRange.StartOffset)
+	assert.Equal(t, 4, blockRange.EndLine)
+	assert.Equal(t, 2, blockRange.EndColumn)
+
+	toys := blocks[0].GetAttribute("toys")
+	require.NotNil(t, toys)
+
+	attributeRange := toys.Range()
+	assert.Equal(t, 2, attributeRange.StartLine)
+	assert.Equal(t, 2, attributeRange.StartColumn)
+	assert.Equal(t, 33, attributeRange.StartOffset)
+	assert.Equal(t, 26, attributeRange.EndColumn)
+
+	elementRange := toys.RangeOfElement(0)
+	assert.Equal(t, 2, elementRange.StartLine)
+	assert.Equal(t, 10, elementRange.StartColumn)
+	assert.Equal(t, 16, elementRange.EndColumn)
+	assert.Equal(t, "ball", string(readRange(t, path, elementRange)[1:5]))
+	assert.True(t, attributeRange.Contains(elementRange))
+
+	assert.Equal(t, "var.toy", string(readRange(t, path, toys.RangeOfElement(1))))
+	assert.Equal(t, attributeRange, toys.RangeOfElement(2))
+
+	// lists which aren't written out element by element fall back to the range of the whole attribute
+	names := blocks[0].GetAttribute("names")
+	require.NotNil(t, names)
+	assert.Equal(t, names.Range(), names.RangeOfElement(0))
+}
+
+func readRange(t *testing.T, path string, r Range) []byte {
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	return data[r.StartOffset:r.EndOffset]
+}
@@ -1,12 +1,34 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
 
-// Range describes an area of code, including the filename it is present in and the lin numbers the code occupies
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Range describes an area of code, including the filename it is present in and the position of the code within it.
+// Lines and columns start at 1, and columns are counted in characters. Offsets are in bytes from the start of the
+// file, and the end position is just after the last character of the code.
 type Range struct {
-	Filename  string `json:"filename"`
-	StartLine int    `json:"start_line"`
-	EndLine   int    `json:"end_line"`
+	Filename    string `json:"filename"`
+	StartLine   int    `json:"start_line"`
+	EndLine     int    `json:"end_line"`
+	StartColumn int    `json:"start_column"`
+	EndColumn   int    `json:"end_column"`
+	StartOffset int    `json:"start_offset"`
+	EndOffset   int    `json:"end_offset"`
+}
+
+func newRange(r hcl.Range) Range {
+	return Range{
+		Filename:    r.Filename,
+		StartLine:   r.Start.Line,
+		EndLine:     r.End.Line,
+		StartColumn: r.Start.Column,
+		EndColumn:   r.End.Column,
+		StartOffset: r.Start.Byte,
+		EndOffset:   r.End.Byte,
+	}
 }
 
 // String creates a human-readable summary of the range
@@ -19,3 +41,8 @@ func (r *Range) String() string {
 	}
 	return fmt.Sprintf("%s:%d", r.Filename, r.StartLine)
 }
+
+// Contains returns true if the other range lies entirely within this one
+func (r Range) Contains(other Range) bool {
+	return r.Filename == other.Filename && r.StartOffset <= other.StartOffset && r.EndOffset >= other.EndOffset
+}
@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FindRootModules returns the root modules within the given directory. A root module is a directory containing
+// terraform files which is not called as a module from any other directory in the tree. Each root module is
+// evaluated on its own, as terraform would when it is applied.
+func (parser *Parser) FindRootModules(path string, excludedDirectories []string) ([]string, error) {
+
+	dirs, err := parser.findConfigDirectories(path, excludedDirectories)
+	if err != nil {
+		return nil, err
+	}
+
+	called := make(map[string]bool)
+	for _, dir := range dirs {
+		blocks, err := parser.loadModule(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, block := range blocks.OfType("module") {
+			source := staticModuleSource(block)
+			if !isLocalSource(source) {
+				continue
+			}
+			if target := filepath.Join(dir, source); target != dir {
+				called[target] = true
+			}
+		}
+	}
+
+	var roots []string
+	for _, dir := range dirs {
+		if !called[dir] {
+			roots = append(roots, dir)
+		}
+	}
+
+	return roots, nil
+}
+
+// findConfigDirectories returns all directories at or below path which contain terraform files, in lexical order
+func (parser *Parser) findConfigDirectories(path string, excludedDirectories []string) ([]string, error) {
+
+	files, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dirs []string
+	var hasConfig bool
+
+FILE:
+	for _, file := range files {
+		if strings.HasPrefix(file.Name(), ".") { //ignore dotfiles (including .terraform!)
+			continue
+		}
+		fullPath := filepath.Join(path, file.Name())
+		if parser.excluded(fullPath) {
+			continue
+		}
+		if file.IsDir() {
+			for _, excluded := range excludedDirectories {
+				if fullPath == excluded {
+					continue FILE
+				}
+			}
+			subDirs, err := parser.findConfigDirectories(fullPath, excludedDirectories)
+			if err != nil {
+				return nil, err
+			}
+			dirs = append(dirs, subDirs...)
+		} else if isConfigFile(file.Name()) {
+			hasConfig = true
+		}
+	}
+
+	if hasConfig {
+		dirs = append([]string{path}, dirs...)
+	}
+
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+func isConfigFile(name string) bool {
+	return strings.HasSuffix(name, ".tf") || strings.HasSuffix(name, ".tf.json")
+}
+
+// staticModuleSource returns the source of a module call, as long as it is a literal string
+func staticModuleSource(block *hcl.Block) string {
+	attrs, _ := block.Body.JustAttributes()
+	sourceAttr, exists := attrs["source"]
+	if !exists {
+		return ""
+	}
+	sourceVal, diagnostics := sourceAttr.Expr.Value(nil)
+	if diagnostics.HasErrors() || sourceVal.IsNull() || !sourceVal.IsKnown() || sourceVal.Type() != cty.String {
+		return ""
+	}
+	return sourceVal.AsString()
+}
@@ -0,0 +1,127 @@
+package parser
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_RootModulesAreEvaluatedSeparately(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"envs/dev/main.tf": `
+variable "acl" {
+	default = "private"
+}
+
+locals {
+	name = "dev"
+}
+
+module "bucket" {
+	source = "../../modules/bucket"
+	acl    = var.acl
+	name   = local.name
+}
+`,
+		"envs/prod/main.tf": `
+variable "acl" {
+	default = "public-read"
+}
+
+locals {
+	name = "prod"
+}
+
+module "bucket" {
+	source = "../../modules/bucket"
+	acl    = var.acl
+	name   = local.name
+}
+`,
+		"modules/bucket/main.tf": `
+variable "acl" {}
+variable "name" {}
+
+resource "cats_bucket" "bucket" {
+	acl  = var.acl
+	name = var.name
+}
+`,
+		"README.md": `not terraform`,
+	})
+
+	parser := New()
+
+	roots, err := parser.FindRootModules(dir, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(dir, "envs", "dev"),
+		filepath.Join(dir, "envs", "prod"),
+	}, roots)
+
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	acls := make(map[string]string)
+	for _, block := range blocks {
+		if block.Type() != "resource" {
+			continue
+		}
+		assert.Equal(t, "module.bucket.cats_bucket.bucket", block.Name())
+		name := block.GetAttribute("name").Value().AsString()
+		acls[name] = block.GetAttribute("acl").Value().AsString()
+		assert.Equal(t, filepath.Join(dir, "envs", name), block.RootModule())
+	}
+
+	assert.Equal(t, map[string]string{
+		"dev":  "private",
+		"prod": "public-read",
+	}, acls)
+}
+
+func Test_ExcludedDirectoriesAreNotRootModules(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf":          `resource "cats_cat" "mittens" {}`,
+		"examples/main.tf": `resource "cats_cat" "boots" {}`,
+	})
+
+	roots, err := New().FindRootModules(dir, []string{filepath.Join(dir, "examples")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{dir}, roots)
+}
+
+func Test_ExcludedPathsAreNotParsed(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf":            `resource "cats_cat" "mittens" {}`,
+		"broken_test.tf":     `resource "cats_cat" {`,
+		"legacy/main.tf":     `resource "cats_cat" "boots" {}`,
+		"legacy/old/main.tf": `resource "cats_cat" "socks" {}`,
+		"current/main.tf":    `resource "cats_cat" "tiddles" {}`,
+		"current/legacy.tf":  `resource "cats_cat" "felix" {}`,
+		"current/broken.tf":  `resource "cats_cat" {`,
+	})
+
+	excluded := map[string]bool{
+		filepath.Join(dir, "broken_test.tf"):       true,
+		filepath.Join(dir, "legacy"):               true,
+		filepath.Join(dir, "current", "broken.tf"): true,
+	}
+	parser := New(OptionWithExcludedPaths(func(path string) bool {
+		return excluded[path]
+	}))
+
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+	assert.Empty(t, parser.Diagnostics())
+
+	var names []string
+	for _, block := range blocks {
+		names = append(names, block.Name())
+	}
+	assert.ElementsMatch(t, []string{"cats_cat.mittens", "cats_cat.tiddles", "cats_cat.felix"}, names)
+}
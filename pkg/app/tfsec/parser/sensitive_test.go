@@ -0,0 +1,91 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SensitiveValues(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "password" {
+	sensitive = true
+	default   = "hunter2"
+}
+
+variable "username" {
+	default = "admin"
+}
+
+locals {
+	credentials = "${var.username}:${var.password}"
+}
+
+resource "cats_cat" "mittens" {
+	owner       = var.username
+	credentials = local.credentials
+}
+
+module "vault" {
+	source   = "./vault"
+	password = var.password
+	username = var.username
+}
+
+resource "cats_cat" "boots" {
+	secret = module.vault.secret
+}
+
+output "secret" {
+	value     = var.username
+	sensitive = true
+}
+`,
+		"vault/main.tf": `
+variable "password" {}
+variable "username" {}
+
+resource "cats_safe" "safe" {
+	combination = var.password
+	label       = var.username
+}
+
+output "secret" {
+	value = var.password
+}
+`,
+	})
+
+	parser := New()
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	named := make(map[string]*Block)
+	for _, block := range blocks {
+		named[block.Name()] = block
+	}
+
+	require.Contains(t, named, "variable.password")
+	assert.True(t, named["variable.password"].GetAttribute("default").IsSensitive())
+	assert.False(t, named["variable.username"].GetAttribute("default").IsSensitive())
+
+	require.Contains(t, named, "cats_cat.mittens")
+	assert.False(t, named["cats_cat.mittens"].GetAttribute("owner").IsSensitive())
+	assert.True(t, named["cats_cat.mittens"].GetAttribute("credentials").IsSensitive())
+
+	require.Contains(t, named, "module.vault.cats_safe.safe")
+	assert.True(t, named["module.vault.cats_safe.safe"].GetAttribute("combination").IsSensitive())
+	assert.False(t, named["module.vault.cats_safe.safe"].GetAttribute("label").IsSensitive())
+
+	require.Contains(t, named, "cats_cat.boots")
+	assert.True(t, named["cats_cat.boots"].GetAttribute("secret").IsSensitive())
+
+	require.Contains(t, named, "output.secret")
+	assert.True(t, named["output.secret"].GetAttribute("value").IsSensitive())
+
+	// the values of sensitive variables, locals and outputs are recorded so they can be masked
+	assert.Equal(t, []string{"admin", "admin:hunter2", "hunter2"}, parser.Sources().Secrets())
+}
@@ -0,0 +1,159 @@
+package parser
+
+import (
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Sources holds the contents of configuration files, so ignore comments and code snippets can be looked up for every
+// result without reading and splitting each file again. Files read by the parser are added as they are parsed, and
+// other files are read from disk the first time they are used. Files which are not on disk, such as those given to
+// ParseSource, can only be found here. Sources also hold the values of sensitive variables, locals and outputs, which
+// must be masked wherever the files are shown.
+type Sources struct {
+	lock    sync.RWMutex
+	files   map[string]*sourceFile
+	secrets map[string]bool
+}
+
+type sourceFile struct {
+	contents string
+	lines    []string
+}
+
+// NewSources creates an empty set of sources
+func NewSources() *Sources {
+	return &Sources{
+		files:   make(map[string]*sourceFile),
+		secrets: make(map[string]bool),
+	}
+}
+
+// Add records the contents of the given file, replacing any already recorded
+func (sources *Sources) Add(filename string, contents []byte) {
+	sources.lock.Lock()
+	defer sources.lock.Unlock()
+	sources.files[filename] = newSourceFile(contents)
+}
+
+func newSourceFile(contents []byte) *sourceFile {
+	return &sourceFile{
+		contents: string(contents),
+		lines:    strings.Split(string(contents), "\n"),
+	}
+}
+
+// AddSecrets records sensitive values found in the files, ignoring empty values
+func (sources *Sources) AddSecrets(secrets ...string) {
+	sources.lock.Lock()
+	defer sources.lock.Unlock()
+	for _, secret := range secrets {
+		if secret != "" {
+			sources.secrets[secret] = true
+		}
+	}
+}
+
+// Secrets returns the sensitive values found in the files, in order
+func (sources *Sources) Secrets() []string {
+	if sources == nil {
+		return nil
+	}
+	sources.lock.RLock()
+	defer sources.lock.RUnlock()
+	var secrets []string
+	for secret := range sources.secrets {
+		secrets = append(secrets, secret)
+	}
+	sort.Strings(secrets)
+	return secrets
+}
+
+// ClearSecrets forgets the sensitive values found in the files, so they are shown as they are
+func (sources *Sources) ClearSecrets() {
+	sources.lock.Lock()
+	defer sources.lock.Unlock()
+	sources.secrets = make(map[string]bool)
+}
+
+// Filenames returns the files whose contents have been recorded, in order
+func (sources *Sources) Filenames() []string {
+	if sources == nil {
+		return nil
+	}
+	sources.lock.RLock()
+	defer sources.lock.RUnlock()
+	var filenames []string
+	for filename, file := range sources.files {
+		if file != nil {
+			filenames = append(filenames, filename)
+		}
+	}
+	sort.Strings(filenames)
+	return filenames
+}
+
+// Contents returns the contents of the given file, and false if it could not be found
+func (sources *Sources) Contents(filename string) (string, bool) {
+	file, ok := sources.get(filename)
+	if !ok {
+		return "", false
+	}
+	return file.contents, true
+}
+
+// Lines returns the lines of the given file, with the first line at index 0, and false if it could not be found. The
+// lines are shared, so must not be modified.
+func (sources *Sources) Lines(filename string) ([]string, bool) {
+	file, ok := sources.get(filename)
+	if !ok {
+		return nil, false
+	}
+	return file.lines, true
+}
+
+// Line returns the given line of a file, counting from 1, and false if the file or line could not be found
+func (sources *Sources) Line(filename string, number int) (string, bool) {
+	lines, ok := sources.Lines(filename)
+	if !ok || number < 1 || number > len(lines) {
+		return "", false
+	}
+	return lines[number-1], true
+}
+
+// get returns the given file, reading it from disk if it hasn't been seen before. A nil set of sources reads the file
+// from disk every time.
+func (sources *Sources) get(filename string) (*sourceFile, bool) {
+
+	if sources == nil {
+		contents, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, false
+		}
+		return newSourceFile(contents), true
+	}
+
+	sources.lock.RLock()
+	file, ok := sources.files[filename]
+	sources.lock.RUnlock()
+	if ok {
+		return file, file != nil
+	}
+
+	sources.lock.Lock()
+	defer sources.lock.Unlock()
+	if file, ok := sources.files[filename]; ok {
+		return file, file != nil
+	}
+	contents, err := ioutil.ReadFile(filename)
+	if err != nil {
+		// remember files which couldn't be read, so they aren't tried again for every result
+		sources.files[filename] = nil
+		return nil, false
+	}
+	file = newSourceFile(contents)
+	sources.files[filename] = file
+	return file, true
+}
@@ -0,0 +1,65 @@
+package parser
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SourcesAreRecordedWhenParsing(t *testing.T) {
+
+	path := createTestFile("test.tf", `
+resource "cats_cat" "mittens" {
+	name = "mittens"
+}
+`)
+
+	parser := New()
+	_, err := parser.ParseDirectory(filepath.Dir(path), nil)
+	require.NoError(t, err)
+
+	// the cached contents are used even after the file changes on disk
+	require.NoError(t, ioutil.WriteFile(path, []byte("# changed"), 0600))
+
+	line, ok := parser.Sources().Line(path, 3)
+	require.True(t, ok)
+	assert.Equal(t, `	name = "mittens"`, line)
+	assert.Equal(t, []string{path}, parser.Sources().Filenames())
+
+	_, ok = parser.Sources().Line(path, 6)
+	assert.False(t, ok)
+	_, ok = parser.Sources().Lines(filepath.Join(filepath.Dir(path), "missing.tf"))
+	assert.False(t, ok)
+}
+
+func Test_ParseSource(t *testing.T) {
+
+	filename := filepath.Join(string(filepath.Separator), "generated", "main.tf")
+
+	parser := New()
+	blocks, err := parser.ParseSource(filename, []byte(`
+variable "name" {
+	default = "mittens"
+}
+
+resource "cats_cat" "cat" {
+	name = var.name
+}
+`))
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	assert.Equal(t, filename, resources[0].Range().Filename)
+	assert.Equal(t, "mittens", resources[0].GetAttribute("name").Value().AsString())
+
+	contents, ok := parser.Sources().Contents(filename)
+	require.True(t, ok)
+	assert.Contains(t, contents, `resource "cats_cat" "cat"`)
+
+	_, err = parser.ParseSource(filename, []byte(`resource "cats_cat" {`))
+	assert.Error(t, err)
+}
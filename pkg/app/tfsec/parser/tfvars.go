@@ -1 +1,217 @@
 package parser
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	hcljson "github.com/hashicorp/hcl/v2/json"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const tfvarsEnvPrefix = "TF_VAR_"
+
+// variableSource is a single --tfvars-file or --var argument. These are kept in the order they were given, as later
+// arguments take precedence over earlier ones.
+type variableSource struct {
+	filename string
+	name     string
+	value    string
+}
+
+// OptionWithTFVarsFile adds a .tfvars or .tfvars.json file to read input variables from
+func OptionWithTFVarsFile(path string) Option {
+	return func(parser *Parser) {
+		parser.variableSources = append(parser.variableSources, variableSource{filename: path})
+	}
+}
+
+// OptionWithRootTFVarsFiles reads input variables for each root module from the .tfvars or .tfvars.json files which
+// filesFor returns for its directory, before those given with OptionWithTFVarsFile and OptionWithVariable
+func OptionWithRootTFVarsFiles(filesFor func(rootModule string) []string) Option {
+	return func(parser *Parser) {
+		parser.rootTFVarsFiles = filesFor
+	}
+}
+
+// OptionWithVariable sets an input variable, as if it had been passed to terraform with -var
+func OptionWithVariable(name string, value string) Option {
+	return func(parser *Parser) {
+		parser.variableSources = append(parser.variableSources, variableSource{name: name, value: value})
+	}
+}
+
+// loadInputVariables reads values for the root module's input variables using the same precedence as terraform. Each
+// of the following overrides the ones before it:
+//
+//   - TF_VAR_ environment variables
+//   - terraform.tfvars
+//   - terraform.tfvars.json
+//   - *.auto.tfvars and *.auto.tfvars.json, in lexical order of filename
+//   - tfvars files configured for the root module
+//   - --tfvars-file and --var arguments, in the order they were given
+func (parser *Parser) loadInputVariables(dir string, blocks hcl.Blocks) (map[string]cty.Value, error) {
+
+	inputVars := make(map[string]cty.Value)
+	types := variableTypes(blocks)
+
+	for _, env := range os.Environ() {
+		if !strings.HasPrefix(env, tfvarsEnvPrefix) {
+			continue
+		}
+		parts := strings.SplitN(strings.TrimPrefix(env, tfvarsEnvPrefix), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		val, err := parseRawVariable(parts[0], parts[1], types)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for environment variable %s%s: %s", tfvarsEnvPrefix, parts[0], err)
+		}
+		inputVars[parts[0]] = val
+	}
+
+	var filenames []string
+	for _, name := range []string{"terraform.tfvars", "terraform.tfvars.json"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			filenames = append(filenames, filepath.Join(dir, name))
+		}
+	}
+
+	// the directory of configuration given to ParseSource may not exist on disk, in which case it has no tfvars files
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var autoFilenames []string
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		if strings.HasSuffix(file.Name(), ".auto.tfvars") || strings.HasSuffix(file.Name(), ".auto.tfvars.json") {
+			autoFilenames = append(autoFilenames, file.Name())
+		}
+	}
+	sort.Strings(autoFilenames)
+	for _, name := range autoFilenames {
+		filenames = append(filenames, filepath.Join(dir, name))
+	}
+
+	// tfvars files which can't be parsed are skipped and reported like configuration files, so the rest of the root
+	// module is still scanned
+	for _, filename := range filenames {
+		if err := parser.readTFVars(filename, inputVars); err != nil {
+			parser.addParseError(filename, err)
+		}
+	}
+
+	var sources []variableSource
+	if parser.rootTFVarsFiles != nil {
+		for _, filename := range parser.rootTFVarsFiles(dir) {
+			sources = append(sources, variableSource{filename: filename})
+		}
+	}
+	for _, source := range append(sources, parser.variableSources...) {
+		if source.filename != "" {
+			err := parser.readTFVars(source.filename, inputVars)
+			if _, invalid := err.(hcl.Diagnostics); invalid {
+				parser.addParseError(source.filename, err)
+			} else if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		val, err := parseRawVariable(source.name, source.value, types)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for variable %s: %s", source.name, err)
+		}
+		inputVars[source.name] = val
+	}
+
+	return inputVars, nil
+}
+
+// readTFVars reads all values from the given .tfvars or .tfvars.json file into inputVars
+func (parser *Parser) readTFVars(filename string, inputVars map[string]cty.Value) error {
+
+	src, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var tfvars *hcl.File
+	var diagnostics hcl.Diagnostics
+	if strings.HasSuffix(filename, ".json") {
+		tfvars, diagnostics = hcljson.Parse(src, filename)
+	} else {
+		tfvars, diagnostics = hclsyntax.ParseConfig(src, filename, hcl.Pos{Line: 1, Column: 1})
+	}
+	if diagnostics != nil && diagnostics.HasErrors() {
+		return diagnostics
+	}
+
+	attrs, diagnostics := tfvars.Body.JustAttributes()
+	if diagnostics != nil && diagnostics.HasErrors() {
+		return diagnostics
+	}
+
+	ctx := &hcl.EvalContext{
+		Functions: Functions(filepath.Dir(filename)),
+	}
+	for _, attr := range attrs {
+		inputVars[attr.Name], _ = attr.Expr.Value(ctx)
+	}
+
+	return nil
+}
+
+// variableTypes returns the type keyword given to each variable declared with a type constraint, or an empty string if
+// the constraint is not a simple keyword, e.g. list(string)
+func variableTypes(blocks hcl.Blocks) map[string]string {
+	types := make(map[string]string)
+	for _, block := range blocks.OfType("variable") {
+		if len(block.Labels) < 1 {
+			continue
+		}
+		attributes, _ := block.Body.JustAttributes()
+		if typeAttr, exists := attributes["type"]; exists {
+			types[block.Labels[0]] = hcl.ExprAsKeyword(typeAttr.Expr)
+		}
+	}
+	return types
+}
+
+// parseRawVariable converts a variable passed as a raw string on the command line or in the environment. Like
+// terraform, the value is taken as a literal string unless the variable is declared with a complex type, in which case
+// it is parsed as an HCL expression.
+func parseRawVariable(name string, raw string, types map[string]string) (cty.Value, error) {
+
+	typeKeyword, declared := types[name]
+	if !declared {
+		return cty.StringVal(raw), nil
+	}
+
+	switch typeKeyword {
+	case "string", "any":
+		return cty.StringVal(raw), nil
+	case "number":
+		return convert.Convert(cty.StringVal(raw), cty.Number)
+	case "bool":
+		return convert.Convert(cty.StringVal(raw), cty.Bool)
+	}
+
+	expr, diagnostics := hclsyntax.ParseExpression([]byte(raw), name, hcl.Pos{Line: 1, Column: 1})
+	if diagnostics != nil && diagnostics.HasErrors() {
+		return cty.NilVal, diagnostics
+	}
+	val, diagnostics := expr.Value(&hcl.EvalContext{})
+	if diagnostics != nil && diagnostics.HasErrors() {
+		return cty.NilVal, diagnostics
+	}
+	return val, nil
+}
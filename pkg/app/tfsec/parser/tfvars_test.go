@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func Test_InputVariablePrecedence(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "from_env" {}
+variable "from_tfvars" {}
+variable "from_tfvars_json" {}
+variable "from_auto" {}
+variable "from_file_flag" {}
+variable "from_var_flag" {}
+variable "ports" {
+	type = list(number)
+}
+variable "count_of_things" {
+	type = number
+}
+
+resource "cats_cat" "mittens" {
+	from_env         = var.from_env
+	from_tfvars      = var.from_tfvars
+	from_tfvars_json = var.from_tfvars_json
+	from_auto        = var.from_auto
+	from_file_flag   = var.from_file_flag
+	from_var_flag    = var.from_var_flag
+	ports            = var.ports
+	count_of_things  = var.count_of_things
+}
+`,
+		"terraform.tfvars": `
+from_env         = "tfvars"
+from_tfvars      = "tfvars"
+from_tfvars_json = "tfvars"
+from_auto        = "tfvars"
+from_file_flag   = "tfvars"
+from_var_flag    = "tfvars"
+`,
+		"terraform.tfvars.json": `{
+	"from_tfvars_json": "tfvars_json",
+	"from_auto": "tfvars_json",
+	"from_file_flag": "tfvars_json",
+	"from_var_flag": "tfvars_json"
+}`,
+		"a.auto.tfvars": `
+from_auto      = "a_auto"
+from_file_flag = "a_auto"
+from_var_flag  = "a_auto"
+`,
+		"b.auto.tfvars.json": `{
+	"from_auto": "b_auto"
+}`,
+		"extra.tfvars": `
+from_file_flag = "file_flag"
+from_var_flag  = "file_flag"
+`,
+	})
+
+	require.NoError(t, os.Setenv("TF_VAR_from_env", "env"))
+	require.NoError(t, os.Setenv("TF_VAR_from_var_flag", "env"))
+	require.NoError(t, os.Setenv("TF_VAR_count_of_things", "3"))
+	defer func() {
+		_ = os.Unsetenv("TF_VAR_from_env")
+		_ = os.Unsetenv("TF_VAR_from_var_flag")
+		_ = os.Unsetenv("TF_VAR_count_of_things")
+	}()
+
+	parser := New(
+		OptionWithVariable("from_var_flag", "overridden"),
+		OptionWithTFVarsFile(filepath.Join(dir, "extra.tfvars")),
+		OptionWithVariable("from_var_flag", "var_flag"),
+		OptionWithVariable("ports", "[22, 443]"),
+	)
+
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	resource := resources[0]
+
+	assert.Equal(t, "tfvars", resource.GetAttribute("from_env").Value().AsString())
+	assert.Equal(t, "tfvars", resource.GetAttribute("from_tfvars").Value().AsString())
+	assert.Equal(t, "tfvars_json", resource.GetAttribute("from_tfvars_json").Value().AsString())
+	assert.Equal(t, "b_auto", resource.GetAttribute("from_auto").Value().AsString())
+	assert.Equal(t, "file_flag", resource.GetAttribute("from_file_flag").Value().AsString())
+	assert.Equal(t, "var_flag", resource.GetAttribute("from_var_flag").Value().AsString())
+
+	ports := resource.GetAttribute("ports").Value()
+	require.True(t, ports.Type().IsTupleType())
+	require.Equal(t, 2, ports.LengthInt())
+	assert.True(t, ports.AsValueSlice()[1].Equals(cty.NumberIntVal(443)).True())
+
+	countOfThings := resource.GetAttribute("count_of_things").Value()
+	require.Equal(t, cty.Number, countOfThings.Type())
+	assert.True(t, countOfThings.Equals(cty.NumberIntVal(3)).True())
+}
+
+func Test_EnvironmentVariableUsedWithoutTFVars(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"main.tf": `
+variable "name" {
+	default = "default"
+}
+
+resource "cats_cat" "mittens" {
+	name = var.name
+}
+`,
+	})
+
+	require.NoError(t, os.Setenv("TF_VAR_name", "env"))
+	defer func() { _ = os.Unsetenv("TF_VAR_name") }()
+
+	blocks, err := New().ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	resources := blocks.OfType("resource")
+	require.Len(t, resources, 1)
+	assert.Equal(t, "env", resources[0].GetAttribute("name").Value().AsString())
+}
+
+func createTestDirectory(files map[string]string) string {
+	dir, err := ioutil.TempDir(os.TempDir(), "tfsec")
+	if err != nil {
+		panic(err)
+	}
+	for filename, contents := range files {
+		path := filepath.Join(dir, filename)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			panic(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(contents), 0755); err != nil {
+			panic(err)
+		}
+	}
+	return dir
+}
+
+func Test_RootTFVarsFilesApplyToEachRootModule(t *testing.T) {
+
+	dir := createTestDirectory(map[string]string{
+		"envs/dev/main.tf": `
+variable "acl" {}
+
+resource "cats_bucket" "bucket" {
+	acl = var.acl
+}
+`,
+		"envs/prod/main.tf": `
+variable "acl" {}
+
+resource "cats_bucket" "bucket" {
+	acl = var.acl
+}
+`,
+		"dev.tfvars":  `acl = "public-read"`,
+		"prod.tfvars": `acl = "private"`,
+		"flag.tfvars": `acl = "authenticated-read"`,
+	})
+
+	parser := New(
+		OptionWithRootTFVarsFiles(func(rootModule string) []string {
+			return []string{filepath.Join(dir, filepath.Base(rootModule)+".tfvars")}
+		}),
+	)
+	blocks, err := parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+
+	acls := make(map[string]string)
+	for _, block := range blocks.OfType("resource") {
+		acls[filepath.Base(block.RootModule())] = block.GetAttribute("acl").Value().AsString()
+	}
+	assert.Equal(t, map[string]string{"dev": "public-read", "prod": "private"}, acls)
+
+	parser = New(
+		OptionWithRootTFVarsFiles(func(rootModule string) []string {
+			return []string{filepath.Join(dir, filepath.Base(rootModule)+".tfvars")}
+		}),
+		OptionWithTFVarsFile(filepath.Join(dir, "flag.tfvars")),
+	)
+	blocks, err = parser.ParseDirectory(dir, nil)
+	require.NoError(t, err)
+	for _, block := range blocks.OfType("resource") {
+		assert.Equal(t, "authenticated-read", block.GetAttribute("acl").Value().AsString())
+	}
+}
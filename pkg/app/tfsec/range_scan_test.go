@@ -0,0 +1,69 @@
+package tfsec
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/checks"
+)
+
+func Test_ResultPointsAtListElement(t *testing.T) {
+
+	results := scanSource(`
+resource "aws_security_group_rule" "my-rule" {
+	type        = "ingress"
+	description = "allow everything in"
+	cidr_blocks = [
+		"10.0.0.0/16",
+		"0.0.0.0/0",
+	]
+}
+`)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, checks.AWSOpenIngressSecurityGroupRule, results[0].RuleID)
+	assert.Equal(t, 7, results[0].Range.StartLine)
+	assert.Equal(t, 7, results[0].Range.EndLine)
+	assert.Equal(t, 3, results[0].Range.StartColumn)
+	assert.Equal(t, 14, results[0].Range.EndColumn)
+	assert.Equal(t, 11, results[0].Range.EndOffset-results[0].Range.StartOffset)
+}
+
+func Test_ResultPointsAtJSONListElement(t *testing.T) {
+
+	results := scanJSONSource(`{
+	"resource": {
+		"aws_security_group_rule": {
+			"my-rule": {
+				"type": "ingress",
+				"description": "allow everything in",
+				"cidr_blocks": ["10.0.0.0/16", "0.0.0.0/0"]
+			}
+		}
+	}
+}`)
+
+	require.Len(t, results, 1)
+	assert.Equal(t, 7, results[0].Range.StartLine)
+	assert.Equal(t, 40, results[0].Range.StartColumn)
+	assert.Equal(t, 51, results[0].Range.EndColumn)
+}
+
+func Test_IgnoreOnAttributeAppliesToListElement(t *testing.T) {
+
+	results := scanSource(`
+resource "aws_security_group_rule" "my-rule" {
+	type        = "ingress"
+	description = "allow everything in"
+	# tfsec:ignore:AWS006
+	cidr_blocks = [
+		"10.0.0.0/16",
+		"0.0.0.0/0",
+	]
+}
+`)
+
+	assert.Len(t, results, 0)
+}
@@ -22,13 +22,15 @@ const (
 )
 
 // Check is a targeted security test which can be applied to terraform templates. It includes the types to run on e.g.
-// "resource", and the labels to run on e.g. "aws_s3_bucket".
+// "resource", and the labels to run on e.g. "aws_s3_bucket". Link is where to find more information about the check,
+// which defaults to the check's page on the tfsec wiki.
 type Check struct {
 	Code           RuleID
 	Description    RuleDescription
 	Provider       RuleProvider
 	RequiredTypes  []string
 	RequiredLabels []string
+	Link           string
 	CheckFunc      func(*Check, *parser.Block, *Context) []Result
 }
 
@@ -89,6 +91,8 @@ func (check *Check) NewResult(description string, r parser.Range, severity Sever
 	}
 }
 
+// NewResultWithValueAnnotation creates a new Result, annotated with the evaluated value of the given attribute if it
+// isn't written out literally. The value is masked in any output if the attribute is sensitive.
 func (check *Check) NewResultWithValueAnnotation(description string, r parser.Range, attr *parser.Attribute, severity Severity) Result {
 
 	if attr == nil || attr.IsLiteral() {
@@ -113,10 +117,23 @@ func (check *Check) NewResultWithValueAnnotation(description string, r parser.Ra
 		return check.NewResult(description, r, severity)
 	}
 
-	return Result{
+	result := Result{
 		RuleID:          check.Code,
 		Description:     description,
 		Range:           r,
 		RangeAnnotation: fmt.Sprintf("[%s] %#v", typeStr, raw),
+		Severity:        severity,
+	}
+	if attr.IsSensitive() {
+		result.Secrets = []string{fmt.Sprintf("%v", raw)}
 	}
+	return result
+}
+
+// NewSensitiveResult creates a new Result for a problem involving a secret, such as a password. The secret is masked
+// wherever the result is output, including in its value annotation and code snippets.
+func (check *Check) NewSensitiveResult(description string, r parser.Range, attr *parser.Attribute, secret string, severity Severity) Result {
+	result := check.NewResultWithValueAnnotation(description, r, attr, severity)
+	result.Secrets = append(result.Secrets, secret)
+	return result
 }
@@ -1,9 +1,17 @@
 package scanner
 
-import "github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+import (
+	"sync"
+
+	"github.com/zclconf/go-cty/cty/function"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+)
 
 type Context struct {
-	blocks parser.Blocks
+	blocks        parser.Blocks
+	functionsLock sync.Mutex
+	functions     map[string]map[string]function.Function
 }
 
 func (c *Context) GetResourcesByType(t string) parser.Blocks {
@@ -15,3 +23,19 @@ func (c *Context) GetResourcesByType(t string) parser.Blocks {
 	}
 	return results
 }
+
+// Functions returns the functions available to expressions in the given directory. The table is built once per
+// directory for each scan and shared by every check.
+func (c *Context) Functions(dir string) map[string]function.Function {
+	c.functionsLock.Lock()
+	defer c.functionsLock.Unlock()
+	if c.functions == nil {
+		c.functions = make(map[string]map[string]function.Function)
+	}
+	functions, ok := c.functions[dir]
+	if !ok {
+		functions = parser.Functions(dir)
+		c.functions[dir] = functions
+	}
+	return functions
+}
@@ -0,0 +1,235 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+)
+
+// ignorePattern matches ignore comments, which may have an expiry date and a reason e.g.
+// tfsec:ignore:AWS006:exp:2026-12-31 reason="vendor VPN". Quotes around the reason may be escaped, as they are within
+// the "//" properties of JSON configurations. Comments starting tfsec:ignore-file: apply to the whole file.
+var ignorePattern = regexp.MustCompile(`tfsec:ignore(-file)?:([\w*-]+)(?::exp:([^\s",\\]+))?(?:\s+reason=\\?"(.*?)\\?")?`)
+
+const expiryFormat = "2006-01-02"
+
+// Ignore is an ignore comment which suppressed a result. An ignore comment with an expiry date stops applying after
+// that day, so the problem it suppressed is reported again.
+type Ignore struct {
+	RuleID RuleID       `json:"rule_id"`
+	Expiry string       `json:"expiry,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+	Range  parser.Range `json:"location"`
+	// WholeFile is true for tfsec:ignore-file: comments, which ignore results anywhere in the file they are at the top of
+	WholeFile bool `json:"whole_file,omitempty"`
+}
+
+// parseIgnores returns the ignore comments on the given line of a file
+func parseIgnores(filename string, number int, line string) []Ignore {
+	var ignores []Ignore
+	for _, match := range ignorePattern.FindAllStringSubmatch(line, -1) {
+		ignores = append(ignores, Ignore{
+			RuleID:    RuleID(match[2]),
+			Expiry:    match[3],
+			Reason:    match[4],
+			Range:     parser.Range{Filename: filename, StartLine: number, EndLine: number},
+			WholeFile: match[1] != "",
+		})
+	}
+	return ignores
+}
+
+// appliesTo returns true if the ignore comment suppresses results of the given check at the given time. Comments with
+// an expiry date which can't be read never apply, so a mistyped date can't suppress a problem forever.
+func (ignore Ignore) appliesTo(code RuleID, now time.Time) bool {
+	if ignore.RuleID != "*" && ignore.RuleID != code {
+		return false
+	}
+	if ignore.Expiry == "" {
+		return true
+	}
+	expiry, err := time.ParseInLocation(expiryFormat, ignore.Expiry, now.Location())
+	if err != nil {
+		return false
+	}
+	return now.Before(expiry.AddDate(0, 0, 1))
+}
+
+func (ignore Ignore) hasValidExpiry() bool {
+	if ignore.Expiry == "" {
+		return true
+	}
+	_, err := time.Parse(expiryFormat, ignore.Expiry)
+	return err == nil
+}
+
+// findIgnore returns the ignore comment which suppresses the result, if there is one. Results which point at part of
+// an attribute, such as a single list element, can also be ignored by a comment on the attribute as a whole. Results
+// can also be ignored for the whole file they are in, or by a comment on any module call the block was evaluated
+// through.
+func (scanner *Scanner) findIgnore(block *parser.Block, result Result) (Ignore, bool) {
+	if ignore, ok := scanner.findIgnoreForRange(result.RuleID, result.Range); ok {
+		return ignore, true
+	}
+	if enclosing, ok := block.EnclosingAttributeRange(result.Range); ok && enclosing != result.Range {
+		if ignore, ok := scanner.findIgnoreForRange(result.RuleID, enclosing); ok {
+			return ignore, true
+		}
+	}
+	if ignore, ok := scanner.findIgnoreForFile(result.RuleID, result.Range.Filename); ok {
+		return ignore, true
+	}
+	for _, call := range block.ModuleCallRanges() {
+		if ignore, ok := scanner.findIgnoreForRange(result.RuleID, call); ok {
+			return ignore, true
+		}
+	}
+	return Ignore{}, false
+}
+
+// findIgnoreForRange looks for an ignore comment for the given check on the lines of the range, or on the line before
+func (scanner *Scanner) findIgnoreForRange(code RuleID, r parser.Range) (Ignore, bool) {
+	now := time.Now()
+	for number := r.StartLine - 1; number <= r.EndLine; number++ {
+		line, ok := scanner.sources.Line(r.Filename, number)
+		if !ok {
+			continue
+		}
+		for _, ignore := range parseIgnores(r.Filename, number, line) {
+			if !ignore.WholeFile && ignore.appliesTo(code, now) {
+				return ignore, true
+			}
+		}
+	}
+	return Ignore{}, false
+}
+
+// findIgnoreForFile looks for a tfsec:ignore-file: comment for the given check at the top of the file
+func (scanner *Scanner) findIgnoreForFile(code RuleID, filename string) (Ignore, bool) {
+	now := time.Now()
+	lines, _ := scanner.sources.Lines(filename)
+	for number := 1; number <= fileHeaderLength(filename, lines); number++ {
+		for _, ignore := range parseIgnores(filename, number, lines[number-1]) {
+			if ignore.WholeFile && ignore.appliesTo(code, now) {
+				return ignore, true
+			}
+		}
+	}
+	return Ignore{}, false
+}
+
+// fileHeaderLength returns the number of lines at the top of a file which are blank or comments, and so can hold
+// tfsec:ignore-file: comments. JSON configurations can only carry comments as "//" properties, which may be anywhere.
+func fileHeaderLength(filename string, lines []string) int {
+	if strings.HasSuffix(filename, ".json") {
+		return len(lines)
+	}
+	inBlockComment := false
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		switch {
+		case inBlockComment:
+			inBlockComment = !strings.Contains(line, "*/")
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, "//"):
+		case strings.HasPrefix(line, "/*"):
+			inBlockComment = !strings.Contains(line, "*/")
+		default:
+			return i
+		}
+	}
+	return len(lines)
+}
+
+// IgnoreProblem describes why an ignore comment didn't ignore any problems
+type IgnoreProblem string
+
+const (
+	IgnoreUnused       IgnoreProblem = "unused"
+	IgnoreExpired      IgnoreProblem = "expired"
+	IgnoreBadExpiry    IgnoreProblem = "invalid_expiry"
+	IgnoreUnknownCheck IgnoreProblem = "unknown_check"
+)
+
+// IgnoreWarning is an ignore comment which didn't ignore any problems. Comments left behind after a problem is fixed
+// should be removed, as they would hide the problem if it came back.
+type IgnoreWarning struct {
+	Ignore  Ignore        `json:"ignore"`
+	Problem IgnoreProblem `json:"problem"`
+}
+
+// Message describes the problem with the ignore comment
+func (warning IgnoreWarning) Message() string {
+	switch warning.Problem {
+	case IgnoreExpired:
+		return fmt.Sprintf("ignore comment for %s expired on %s", warning.Ignore.RuleID, warning.Ignore.Expiry)
+	case IgnoreBadExpiry:
+		return fmt.Sprintf("ignore comment for %s has an expiry date '%s' which isn't in the form YYYY-MM-DD", warning.Ignore.RuleID, warning.Ignore.Expiry)
+	case IgnoreUnknownCheck:
+		return fmt.Sprintf("ignore comment for unknown check %s", warning.Ignore.RuleID)
+	default:
+		return fmt.Sprintf("ignore comment for %s did not ignore any problems", warning.Ignore.RuleID)
+	}
+}
+
+// findIgnoreWarnings returns the ignore comments in the files of the scanned modules which weren't used to ignore any
+// results, in order of their location
+func (scanner *Scanner) findIgnoreWarnings(blocks []*parser.Block, index *checkIndex, blockIgnores [][]Ignore) []IgnoreWarning {
+
+	used := make(map[Ignore]bool)
+	for _, ignores := range blockIgnores {
+		for _, ignore := range ignores {
+			used[ignore] = true
+		}
+	}
+
+	known := make(map[RuleID]bool)
+	for _, check := range index.checks {
+		known[check.Code] = true
+	}
+
+	// every file of the scanned modules is checked, including override files, whose blocks are merged into those of
+	// other files
+	filenames := make(map[string]bool)
+	dirs := make(map[string]bool)
+	for _, block := range blocks {
+		filenames[block.Range().Filename] = true
+		dirs[filepath.Dir(block.Range().Filename)] = true
+	}
+	for _, filename := range scanner.sources.Filenames() {
+		if dirs[filepath.Dir(filename)] {
+			filenames[filename] = true
+		}
+	}
+	var sortedFilenames []string
+	for filename := range filenames {
+		sortedFilenames = append(sortedFilenames, filename)
+	}
+	sort.Strings(sortedFilenames)
+
+	now := time.Now()
+	var warnings []IgnoreWarning
+	for _, filename := range sortedFilenames {
+		lines, _ := scanner.sources.Lines(filename)
+		for i, line := range lines {
+			for _, ignore := range parseIgnores(filename, i+1, line) {
+				switch {
+				case used[ignore]:
+				case ignore.RuleID != "*" && !known[ignore.RuleID]:
+					warnings = append(warnings, IgnoreWarning{Ignore: ignore, Problem: IgnoreUnknownCheck})
+				case !ignore.hasValidExpiry():
+					warnings = append(warnings, IgnoreWarning{Ignore: ignore, Problem: IgnoreBadExpiry})
+				case !ignore.appliesTo(ignore.RuleID, now):
+					warnings = append(warnings, IgnoreWarning{Ignore: ignore, Problem: IgnoreExpired})
+				default:
+					warnings = append(warnings, IgnoreWarning{Ignore: ignore, Problem: IgnoreUnused})
+				}
+			}
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"sort"
+
+	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
+)
+
+// anyValue is the index key for checks which don't require a particular block type or label
+const anyValue = ""
+
+// checkIndex finds the checks which are required for a block by looking up the block's type and first label, rather
+// than testing every check against every block
+type checkIndex struct {
+	checks []Check
+	// positions maps each block type, then each first label, to the positions in checks of the checks which require them
+	positions map[string]map[string][]int
+}
+
+func newCheckIndex(checks []Check) *checkIndex {
+	index := &checkIndex{
+		checks:    checks,
+		positions: make(map[string]map[string][]int),
+	}
+	for i, check := range checks {
+		if check.CheckFunc == nil {
+			continue
+		}
+		types := check.RequiredTypes
+		if len(types) == 0 {
+			types = []string{anyValue}
+		}
+		labels := check.RequiredLabels
+		if len(labels) == 0 {
+			labels = []string{anyValue}
+		}
+		for _, requiredType := range types {
+			if index.positions[requiredType] == nil {
+				index.positions[requiredType] = make(map[string][]int)
+			}
+			for _, requiredLabel := range labels {
+				index.positions[requiredType][requiredLabel] = append(index.positions[requiredType][requiredLabel], i)
+			}
+		}
+	}
+	return index
+}
+
+// forBlock returns the checks which are required for the given block, in the order they were registered
+func (index *checkIndex) forBlock(block *parser.Block) []*Check {
+
+	var positions []int
+	for _, blockType := range []string{block.Type(), anyValue} {
+		byLabel := index.positions[blockType]
+		if byLabel == nil {
+			continue
+		}
+		if len(block.Labels()) > 0 {
+			positions = append(positions, byLabel[block.Labels()[0]]...)
+		}
+		positions = append(positions, byLabel[anyValue]...)
+	}
+	sort.Ints(positions)
+
+	var checks []*Check
+	for i, position := range positions {
+		if i > 0 && positions[i-1] == position {
+			continue
+		}
+		checks = append(checks, &index.checks[position])
+	}
+	return checks
+}
@@ -5,7 +5,7 @@ import (
 	"sync"
 )
 
-var checkLock sync.Mutex
+var checkLock sync.RWMutex
 var registeredChecks []Check
 
 // RegisterCheck registers a new Check which should be run on future scans
@@ -23,7 +23,12 @@ func RegisterCheck(check Check) {
 	registeredChecks = append(registeredChecks, check)
 }
 
-// GetRegisteredChecks provides all Checks which have been registered with this package
+// GetRegisteredChecks provides all Checks which have been registered with this package. The slice is a copy, so it is
+// safe to use while further checks are registered.
 func GetRegisteredChecks() []Check {
-	return registeredChecks
+	checkLock.RLock()
+	defer checkLock.RUnlock()
+	checks := make([]Check, len(registeredChecks))
+	copy(checks, registeredChecks)
+	return checks
 }
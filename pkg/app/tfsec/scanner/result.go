@@ -13,6 +13,25 @@ type Result struct {
 	Description     string       `json:"description"`
 	RangeAnnotation string       `json:"-"`
 	Severity        Severity     `json:"severity"`
+	RootModule      string       `json:"root_module"`
+	// Address is the address of the block at fault e.g. module.vpc.aws_subnet.public[0]
+	Address      string `json:"address"`
+	BlockType    string `json:"block_type"`
+	ResourceType string `json:"resource_type"`
+	// ModulePath is the address of the module call the block was evaluated through e.g. module.vpc
+	ModulePath string `json:"module_path"`
+	// Provider is the provider configuration of the block at fault e.g. aws.west
+	Provider string `json:"provider"`
+	// AttributePath is the path of the attribute at fault within the block e.g. ingress.cidr_blocks
+	AttributePath string `json:"attribute_path"`
+	// Fingerprint identifies the problem by the check, the address of the block and the attribute at fault, so that
+	// it stays the same when lines are added above it or the block is moved to another file
+	Fingerprint string `json:"fingerprint"`
+	// Ignored is the comment which ignored the result. Ignored results are only returned by scanners created with
+	// OptionIncludeIgnored.
+	Ignored *Ignore `json:"ignored,omitempty"`
+	// Secrets holds any sensitive values the result reveals, which are masked wherever the result is output
+	Secrets []string `json:"-"`
 }
 
 type Severity string
@@ -22,3 +41,20 @@ const (
 	SeverityWarning Severity = "WARNING"
 	SeverityInfo    Severity = "INFO"
 )
+
+var severityRanks = map[Severity]int{
+	SeverityInfo:    1,
+	SeverityWarning: 2,
+	SeverityError:   3,
+}
+
+// IsValid returns true if the severity is one of ERROR, WARNING or INFO
+func (severity Severity) IsValid() bool {
+	_, valid := severityRanks[severity]
+	return valid
+}
+
+// IsAtLeast returns true if the severity is as severe as, or more severe than, the given severity
+func (severity Severity) IsAtLeast(minimum Severity) bool {
+	return severityRanks[severity] >= severityRanks[minimum]
+}
@@ -1,20 +1,65 @@
 package scanner
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
-	"io/ioutil"
+	"runtime"
+	"sort"
 	"strings"
+	"sync"
 
 	"github.com/hemanthgk10/tfsec/pkg/app/tfsec/parser"
 )
 
 // Scanner scans HCL blocks by running all registered checks against them
 type Scanner struct {
+	concurrency    int
+	sources        *parser.Sources
+	includeIgnored bool
+	ignoreWarnings []IgnoreWarning
+}
+
+// Option configures optional behaviour of a Scanner
+type Option func(scanner *Scanner)
+
+// OptionWithConcurrency sets the number of blocks which are scanned at the same time. Values below 1 use the number
+// of CPUs.
+func OptionWithConcurrency(concurrency int) Option {
+	return func(scanner *Scanner) {
+		scanner.concurrency = concurrency
+	}
+}
+
+// OptionWithSources sets the file contents which ignore comments are looked up in, which should be those of the parser
+// which created the blocks. Without it, files are read from disk as they are needed.
+func OptionWithSources(sources *parser.Sources) Option {
+	return func(scanner *Scanner) {
+		scanner.sources = sources
+	}
+}
+
+// OptionIncludeIgnored returns results which have been ignored with a comment, along with the comment, rather than
+// leaving them out
+func OptionIncludeIgnored() Option {
+	return func(scanner *Scanner) {
+		scanner.includeIgnored = true
+	}
 }
 
 // New creates a new Scanner
-func New() *Scanner {
-	return &Scanner{}
+func New(options ...Option) *Scanner {
+	scanner := &Scanner{}
+	for _, option := range options {
+		option(scanner)
+	}
+	if scanner.concurrency < 1 {
+		scanner.concurrency = runtime.NumCPU()
+	}
+	if scanner.sources == nil {
+		scanner.sources = parser.NewSources()
+	}
+	return scanner
 }
 
 // Find element in list
@@ -29,52 +74,138 @@ func checkInList(code RuleID, list []string) bool {
 }
 
 // Scan takes all available hcl blocks and an optional context, and returns a slice of results. Each result indicates a potential security problem.
+// Blocks are shared out between the scanner's workers, and the results are sorted by location so the output is the
+// same however the work was shared out.
 func (scanner *Scanner) Scan(blocks []*parser.Block, excludedChecksList []string) []Result {
-	var results []Result
+
+	index := newCheckIndex(GetRegisteredChecks())
 	context := &Context{blocks: blocks}
-	for _, block := range blocks {
-		for _, check := range GetRegisteredChecks() {
-			if check.IsRequiredForBlock(block) {
-				for _, result := range check.Run(block, context) {
-					if !scanner.checkRangeIgnored(result.RuleID, result.Range) && !checkInList(result.RuleID, excludedChecksList) {
-						result.Link = fmt.Sprintf("https://github.com/tfsec/tfsec/wiki/%s", result.RuleID)
-						results = append(results, result)
-					}
-				}
+
+	workers := scanner.concurrency
+	if workers > len(blocks) {
+		workers = len(blocks)
+	}
+
+	blockResults := make([][]Result, len(blocks))
+	blockIgnores := make([][]Ignore, len(blocks))
+	positions := make(chan int)
+	var wg sync.WaitGroup
+	for worker := 0; worker < workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for position := range positions {
+				blockResults[position], blockIgnores[position] = scanner.scanBlock(blocks[position], index, context, excludedChecksList)
+			}
+		}()
+	}
+	for position := range blocks {
+		positions <- position
+	}
+	close(positions)
+	wg.Wait()
+
+	// fingerprints are assigned in block order, so results with the same check, address and attribute are numbered
+	// the same way on every scan. Ignored results are numbered separately, so including them doesn't change the
+	// fingerprints of the others.
+	var results []Result
+	occurrences := make(map[string]int)
+	ignoredOccurrences := make(map[string]int)
+	for _, resultsForBlock := range blockResults {
+		for _, result := range resultsForBlock {
+			if result.Ignored != nil {
+				result.Fingerprint = fingerprint(result, ignoredOccurrences)
+			} else {
+				result.Fingerprint = fingerprint(result, occurrences)
 			}
+			results = append(results, result)
 		}
 	}
+
+	scanner.ignoreWarnings = scanner.findIgnoreWarnings(blocks, index, blockIgnores)
+
+	sortResults(results)
 	return results
 }
 
-func (scanner *Scanner) checkRangeIgnored(code RuleID, r parser.Range) bool {
-	raw, err := ioutil.ReadFile(r.Filename)
-	if err != nil {
-		return false
-	}
-	ignoreAll := "tfsec:ignore:*"
-	ignoreCode := fmt.Sprintf("tfsec:ignore:%s", code)
-	lines := append([]string{""}, strings.Split(string(raw), "\n")...)
-	for number := r.StartLine; number <= r.EndLine; number++ {
-		if number <= 0 || number >= len(lines) {
-			continue
-		}
-		if strings.Contains(lines[number], ignoreAll) || strings.Contains(lines[number], ignoreCode) {
-			return true
+// IgnoreWarnings returns the ignore comments found by the last scan which didn't ignore any problems, because they
+// are no longer needed, have expired, or name a check which doesn't exist
+func (scanner *Scanner) IgnoreWarnings() []IgnoreWarning {
+	return scanner.ignoreWarnings
+}
+
+// scanBlock runs the required checks against the block, returning the results along with the ignore comments which
+// suppressed any of them. Results of excluded checks are left out, but still count towards the ignore comments used.
+func (scanner *Scanner) scanBlock(block *parser.Block, index *checkIndex, context *Context, excludedChecksList []string) ([]Result, []Ignore) {
+	var results []Result
+	var used []Ignore
+	for _, check := range index.forBlock(block) {
+		for _, result := range check.Run(block, context) {
+			ignore, ignored := scanner.findIgnore(block, result)
+			if ignored {
+				used = append(used, ignore)
+			}
+			if checkInList(result.RuleID, excludedChecksList) || (ignored && !scanner.includeIgnored) {
+				continue
+			}
+			if ignored {
+				result.Ignored = &ignore
+			}
+			result.Link = check.Link
+			if result.Link == "" {
+				result.Link = fmt.Sprintf("https://github.com/tfsec/tfsec/wiki/%s", result.RuleID)
+			}
+			result.RootModule = block.RootModule()
+			setMetadata(&result, block)
+			results = append(results, result)
 		}
 	}
+	return results, used
+}
 
-	if r.StartLine-1 > 0 {
-		line := lines[r.StartLine-1]
-		line = strings.TrimSpace(strings.ReplaceAll(strings.ReplaceAll(line, "//", ""), "#", ""))
-		segments := strings.Split(line, " ")
-		for _, segment := range segments {
-			if segment == ignoreAll || segment == ignoreCode {
-				return true
-			}
+// sortResults orders results by file and position, then by check and resource
+func sortResults(results []Result) {
+	sort.SliceStable(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		switch {
+		case a.Range.Filename != b.Range.Filename:
+			return a.Range.Filename < b.Range.Filename
+		case a.Range.StartLine != b.Range.StartLine:
+			return a.Range.StartLine < b.Range.StartLine
+		case a.Range.StartColumn != b.Range.StartColumn:
+			return a.Range.StartColumn < b.Range.StartColumn
+		case a.Range.EndLine != b.Range.EndLine:
+			return a.Range.EndLine < b.Range.EndLine
+		case a.Range.EndColumn != b.Range.EndColumn:
+			return a.Range.EndColumn < b.Range.EndColumn
+		case a.RuleID != b.RuleID:
+			return a.RuleID < b.RuleID
+		default:
+			return a.Address < b.Address
 		}
+	})
+}
 
+// setMetadata records which block, and which attribute of it, the result is about
+func setMetadata(result *Result, block *parser.Block) {
+	result.Address = block.Name()
+	result.BlockType = block.Type()
+	if (block.Type() == "resource" || block.Type() == "data") && len(block.Labels()) > 0 {
+		result.ResourceType = block.Labels()[0]
 	}
+	result.ModulePath = block.ModulePath()
+	result.Provider = block.Provider()
+	result.AttributePath = block.AttributePath(result.Range)
+}
 
-	return false
+// fingerprint returns a hash of the check, block address and attribute path of the result. The address includes the
+// module path, block type and resource type. Line numbers and filenames are left out so the fingerprint is stable as
+// code moves around. Where a check reports several problems with the same attribute, such as two sensitive
+// environment variables in one container definition, they are told apart by the order they were reported in.
+func fingerprint(result Result, occurrences map[string]int) string {
+	key := strings.Join([]string{string(result.RuleID), result.Address, result.AttributePath}, "|")
+	occurrence := occurrences[result.RootModule+"|"+key]
+	occurrences[result.RootModule+"|"+key]++
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", key, occurrence)))
+	return hex.EncodeToString(sum[:16])
 }
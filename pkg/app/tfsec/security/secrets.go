@@ -0,0 +1,213 @@
+package security
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Detector recognises a secret from the name it is stored under and/or the value itself
+type Detector struct {
+	Name        string
+	Description string
+	match       func(name string, value string) bool
+}
+
+// Detection describes which detector recognised a secret
+type Detection struct {
+	Detector    string
+	Description string
+}
+
+// NewPatternDetector creates a Detector which recognises values matching the given regular expression
+func NewPatternDetector(name string, description string, pattern string) (Detector, error) {
+	if name == "" {
+		return Detector{}, fmt.Errorf("secret pattern has no name")
+	}
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return Detector{}, fmt.Errorf("invalid secret pattern '%s': %s", name, err)
+	}
+	if description == "" {
+		description = name
+	}
+	return Detector{
+		Name:        name,
+		Description: description,
+		match: func(_ string, value string) bool {
+			return expression.MatchString(value)
+		},
+	}, nil
+}
+
+func mustPatternDetector(name string, description string, pattern string) Detector {
+	detector, err := NewPatternDetector(name, description, pattern)
+	if err != nil {
+		panic(err)
+	}
+	return detector
+}
+
+// valueDetectors recognise well known token formats, and are tried before any other detector
+var valueDetectors = []Detector{
+	mustPatternDetector("aws-access-key", "AWS access key", `\b(A3T[A-Z0-9]|AKIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA|ASIA)[A-Z0-9]{16}\b`),
+	mustPatternDetector("gcp-service-account", "GCP service account key", `(?s)"type"\s*:\s*"service_account".*"private_key"|(?s)"private_key".*"type"\s*:\s*"service_account"`),
+	mustPatternDetector("private-key", "PEM private key", `-----BEGIN[A-Z ]* PRIVATE KEY( BLOCK)?-----`),
+	mustPatternDetector("github-token", "GitHub token", `\b((ghp|gho|ghu|ghs|ghr)_[A-Za-z0-9]{36}|github_pat_[A-Za-z0-9_]{22,})\b`),
+	mustPatternDetector("slack-token", "Slack token", `\bxox[abposr]-[0-9A-Za-z-]{10,}`),
+	mustPatternDetector("jwt", "JSON web token", `\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{10,}`),
+}
+
+var entropyDetector = Detector{
+	Name:        "high-entropy",
+	Description: "high entropy string",
+	match: func(name string, value string) bool {
+		return !isRandomButPublicName(name) && !isBlobName(name) && !isPublicKey(value) && hasHighEntropyToken(value)
+	},
+}
+
+var nameDetector = Detector{
+	Name:        "sensitive-name",
+	Description: "sensitive name",
+	match: func(name string, _ string) bool {
+		return IsSensitiveAttribute(name)
+	},
+}
+
+var customLock sync.RWMutex
+var customDetectors []Detector
+
+// AddSecretPattern registers a detector for values matching the given regular expression, so that secrets in
+// formats specific to a team can be found alongside the built in ones
+func AddSecretPattern(name string, pattern string) error {
+	detector, err := NewPatternDetector(name, "", pattern)
+	if err != nil {
+		return err
+	}
+	customLock.Lock()
+	defer customLock.Unlock()
+	for _, existing := range valueDetectors {
+		if existing.Name == name {
+			return fmt.Errorf("secret pattern already exists with name '%s'", name)
+		}
+	}
+	for _, existing := range customDetectors {
+		if existing.Name == name {
+			return fmt.Errorf("secret pattern already exists with name '%s'", name)
+		}
+	}
+	customDetectors = append(customDetectors, detector)
+	return nil
+}
+
+// DetectSecret returns the first detector to recognise the given value, or the name it is stored under, as a secret.
+// Known token formats are tried first, then any patterns added with AddSecretPattern, then the entropy of the value,
+// and finally the name.
+func DetectSecret(name string, value string) (Detection, bool) {
+
+	if value == "" {
+		return Detection{}, false
+	}
+
+	customLock.RLock()
+	detectors := append(append([]Detector{}, valueDetectors...), customDetectors...)
+	customLock.RUnlock()
+	detectors = append(detectors, entropyDetector, nameDetector)
+
+	for _, detector := range detectors {
+		if detector.match(name, value) {
+			return Detection{
+				Detector:    detector.Name,
+				Description: detector.Description,
+			}, true
+		}
+	}
+
+	return Detection{}, false
+}
+
+const minimumEntropyTokenLength = 20
+const minimumHexTokenLength = 32
+const base64EntropyThreshold = 4.5
+const hexEntropyThreshold = 3.5
+
+var tokenSeparator = regexp.MustCompile(`[^A-Za-z0-9+/=_-]+`)
+var hexToken = regexp.MustCompile(`^[0-9a-fA-F]+$`)
+
+// hasHighEntropyToken returns true if any word of the given value looks randomly generated, such as a key or password
+func hasHighEntropyToken(value string) bool {
+	for _, token := range tokenSeparator.Split(value, -1) {
+		if len(token) < minimumEntropyTokenLength {
+			continue
+		}
+		if hexToken.MatchString(token) {
+			if len(token) >= minimumHexTokenLength && shannonEntropy(token) > hexEntropyThreshold {
+				return true
+			}
+			continue
+		}
+		// long words and identifiers are rarely secrets, so only mixtures of letters and digits are considered
+		if !strings.ContainsAny(token, "0123456789") || !strings.ContainsAny(strings.ToLower(token), "abcdefghijklmnopqrstuvwxyz") {
+			continue
+		}
+		if shannonEntropy(token) > base64EntropyThreshold {
+			return true
+		}
+	}
+	return false
+}
+
+// shannonEntropy returns the average number of bits of information held by each character of the given string
+func shannonEntropy(data string) float64 {
+	counts := make(map[rune]int)
+	for _, r := range data {
+		counts[r]++
+	}
+	var entropy float64
+	length := float64(len(data))
+	for _, count := range counts {
+		frequency := float64(count) / length
+		entropy -= frequency * math.Log2(frequency)
+	}
+	return entropy
+}
+
+// isRandomButPublicName returns true for names of values which are random by design but not secret, such as hashes
+// and public keys
+func isRandomButPublicName(name string) bool {
+	name = strings.ToLower(name)
+	for _, part := range []string{"hash", "checksum", "digest", "etag", "fingerprint", "sha1", "sha256", "sha512", "md5", "public", "certificate"} {
+		if strings.Contains(name, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// isBlobName returns true for names of values which hold encoded or generated content, such as base64 user data,
+// policy documents and module sources, whose long tokens look random without being secret. Known token formats are
+// still detected in these values.
+func isBlobName(name string) bool {
+	name = strings.ToLower(name)
+	switch {
+	case name == "user_data", name == "source", name == "policy",
+		strings.HasSuffix(name, "_policy"),
+		strings.Contains(name, "base64"):
+		return true
+	}
+	return false
+}
+
+var publicKeyPrefixes = []string{"ssh-rsa ", "ssh-ed25519 ", "ssh-dss ", "ecdsa-sha2-", "-----BEGIN CERTIFICATE-----", "-----BEGIN PUBLIC KEY-----"}
+
+func isPublicKey(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, prefix := range publicKeyPrefixes {
+		if strings.HasPrefix(value, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -37,9 +37,18 @@ func scanSource(source string) []scanner.Result {
 	return scanner.New().Scan(blocks, excludedChecksList)
 }
 
+func scanJSONSource(source string) []scanner.Result {
+	blocks := createBlocksFromSourceFile("test.tf.json", source)
+	return scanner.New().Scan(blocks, excludedChecksList)
+}
+
 func createBlocksFromSource(source string) []*parser.Block {
-	path := createTestFile("test.tf", source)
-	blocks, err := parser.New().ParseDirectory(filepath.Dir(path), nil, "")
+	return createBlocksFromSourceFile("test.tf", source)
+}
+
+func createBlocksFromSourceFile(filename string, source string) []*parser.Block {
+	path := createTestFile(filename, source)
+	blocks, err := parser.New().ParseDirectory(filepath.Dir(path), nil)
 	if err != nil {
 		panic(err)
 	}